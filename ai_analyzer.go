@@ -2,90 +2,364 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/google/go-github/v57/github"
+	log "github.com/sirupsen/logrus"
 	"github.com/tmc/langchaingo/llms"
-	"github.com/tmc/langchaingo/llms/openai"
 )
 
+const (
+	// 未配置时每批issues的token上限
+	defaultAIChunkTokens = 6000
+
+	// 未配置时同时进行的AI请求数
+	defaultAIConcurrency = 4
+
+	// 为分批提示词的说明部分预留的token数
+	aiPromptOverheadTokens = 200
+)
+
+// 优先使用参数中的AI token，没有提供时从环境变量获取
+func aiTokenOrEnv(token string) string {
+	if token == "" {
+		return os.Getenv(EnvAIToken)
+	}
+	return token
+}
+
 // 使用AI生成issues总结
-func generateAISummary(issues []*github.Issue, outputDirPath, summaryFile, aiToken, aiModel, aiBaseURL string) error {
-	// 准备AI分析的输入数据
-	var issuesData strings.Builder
-	issuesData.WriteString("以下是GitHub仓库的issues列表，请分析这些issues并提供总结：\n\n")
+//
+// issues较多时按token数分批，各批并行生成部分总结，再将部分总结合并为最终报告
+func generateAISummary(issues []*github.Issue, outputDirPath, repoName string, cfg *Config) error {
+	chunkTokens := cfg.AIChunkTokens
+	if chunkTokens <= 0 {
+		chunkTokens = defaultAIChunkTokens
+	}
 
-	// 构建issues表格数据
-	issuesData.WriteString("| 编号 | 标题 | 状态 | 创建时间 | 标签 |\n")
-	issuesData.WriteString("|------|------|------|----------|------|\n")
+	style, err := getReportStyle(cfg.ReportStyle)
+	if err != nil {
+		return err
+	}
+	tmpl, err := loadPromptTemplate(cfg.PromptTemplate, style)
+	if err != nil {
+		return err
+	}
+	var outputInstruction string
+	if cfg.AIJSONOutput {
+		outputInstruction = structuredOutputInstruction(style)
+	}
 
-	for _, issue := range issues {
-		// 获取标签
-		var labels []string
-		for _, label := range issue.Labels {
-			labels = append(labels, label.GetName())
-		}
-		labelStr := strings.Join(labels, ", ")
+	client, err := newAIClient(cfg, outputDirPath)
+	if err != nil {
+		return err
+	}
 
-		// 添加issue行
-		issuesData.WriteString(fmt.Sprintf("| #%d | %s | %s | %s | %s |\n",
-			issue.GetNumber(),
-			issue.GetTitle(),
-			issue.GetState(),
-			issue.GetCreatedAt().Format("2006-01-02"),
-			labelStr))
+	ctx := context.Background()
+	summaryPath := filepath.Join(outputDirPath, summaryFileName(cfg))
 
-		// 添加issue描述（如果有）
-		if issue.GetBody() != "" {
-			issuesData.WriteString(fmt.Sprintf("\n**Issue #%d 描述**:\n%s\n\n",
-				issue.GetNumber(), issue.GetBody()))
+	// 流式输出时最终总结边生成边写到控制台和临时文件，成功后再写入完整报告并替换总结文件，
+	// 失败时保留原有的总结文件
+	var finalOptions []llms.CallOption
+	var stream *os.File
+	if cfg.AIStream {
+		stream, err = os.CreateTemp(outputDirPath, summaryFileName(cfg)+".*.tmp")
+		if err != nil {
+			return fmt.Errorf("创建总结文件失败: %w", err)
 		}
+		defer os.Remove(stream.Name())
+		defer stream.Close()
+		fmt.Fprintf(stream, "# %s\n\n*正在生成...*\n\n## AI分析\n\n", style.Title)
+		finalOptions = append(finalOptions, streamOutput(stream))
 	}
 
-	llm, err := openai.New(openai.WithToken(aiToken), openai.WithModel(aiModel), openai.WithBaseURL(aiBaseURL))
+	// 预留模板本身占用的token
+	data := newPromptData(issues, repoName)
+	overhead, err := renderPrompt(tmpl, data)
 	if err != nil {
-		return fmt.Errorf("创建AI客户端失败: %w", err)
+		return err
 	}
+	overheadTokens := countTokens(overhead+outputInstruction) + aiPromptOverheadTokens
 
-	completion, err := llms.GenerateFromSinglePrompt(context.Background(), llm, issuesData.String())
-	if err != nil {
-		return fmt.Errorf("发送AI请求失败: %w", err)
+	chunks := chunkIssuesForPrompt(issues, chunkTokens-overheadTokens)
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		data.IssueTable, data.Batch, data.BatchCount = chunk, i+1, len(chunks)
+		if prompts[i], err = renderPrompt(tmpl, data); err != nil {
+			return err
+		}
+	}
+
+	var completion string
+	var skipped int
+	if len(chunks) == 1 {
+		completion, err = generateText(ctx, client, prompts[0]+outputInstruction, finalOptions...)
+		if err != nil {
+			return err
+		}
+	} else {
+		var partials []string
+		partials, skipped, err = mapIssueChunks(ctx, client, prompts, cfg.AIConcurrency)
+		if err != nil {
+			return err
+		}
+		completion, err = reducePartialSummaries(ctx, client, partials, chunkTokens, style, outputInstruction, finalOptions...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cfg.AIStream {
+		fmt.Println()
+	}
+
+	// 结构化输出渲染为固定章节，解析失败时保留原始内容
+	if cfg.AIJSONOutput {
+		if rendered, err := renderStructuredReport(completion, style); err != nil {
+			log.Printf("警告: %v，使用AI返回的原始内容", err)
+		} else {
+			completion = rendered
+		}
 	}
 
 	// 构建总结文件内容
 	var summary strings.Builder
-	summary.WriteString("# GitHub Issues 分析总结\n\n")
+	summary.WriteString(fmt.Sprintf("# %s\n\n", style.Title))
 	summary.WriteString("*由AI自动生成*\n\n")
+	if skipped > 0 {
+		summary.WriteString(fmt.Sprintf("> 注意: 受token上限限制，共有 %d/%d 批issues未参与分析\n\n", skipped, len(chunks)))
+	}
 	summary.WriteString("## AI分析\n\n")
 	summary.WriteString(completion)
-	summary.WriteString("\n\n## Issues列表\n\n")
+	summary.WriteString("\n\n")
+
+	summary.WriteString(summaryIssuesHeading)
+
+	// 导出文件脱敏时，表格中的标题同样脱敏
+	r, err := newExportRedactor(cfg)
+	if err != nil {
+		return err
+	}
 
 	// 添加issues表格
 	summary.WriteString("| 编号 | 标题 | 状态 | 创建时间 | 标签 |\n")
 	summary.WriteString("|------|------|------|----------|------|\n")
 
 	for _, issue := range issues {
-		// 获取标签
-		var labels []string
-		for _, label := range issue.Labels {
-			labels = append(labels, label.GetName())
-		}
-		labelStr := strings.Join(labels, ", ")
+		title := r.redact(issue.GetTitle(), fmt.Sprintf("issue #%d", issue.GetNumber()))
 
 		// 添加issue行
 		summary.WriteString(fmt.Sprintf("| [#%d](%s) | %s | %s | %s | %s |\n",
 			issue.GetNumber(),
 			issue.GetHTMLURL(),
-			issue.GetTitle(),
+			title,
 			issue.GetState(),
 			issue.GetCreatedAt().Format("2006-01-02"),
-			labelStr))
+			issueLabelsString(issue)))
 	}
 
+	// 用量统计
+	summary.WriteString(fmt.Sprintf("\n---\n\n*%s*\n", runAIUsage.String(cfg)))
+
 	// 写入文件
-	summaryPath := filepath.Join(outputDirPath, summaryFile)
-	return os.WriteFile(summaryPath, []byte(summary.String()), 0644)
+	if stream == nil {
+		return os.WriteFile(summaryPath, []byte(summary.String()), 0644)
+	}
+	if err := stream.Close(); err != nil {
+		return fmt.Errorf("保存总结文件失败: %w", err)
+	}
+	if err := os.WriteFile(stream.Name(), []byte(summary.String()), 0644); err != nil {
+		return fmt.Errorf("保存总结文件失败: %w", err)
+	}
+	if err := os.Chmod(stream.Name(), 0644); err != nil {
+		return fmt.Errorf("保存总结文件失败: %w", err)
+	}
+	if err := os.Rename(stream.Name(), summaryPath); err != nil {
+		return fmt.Errorf("保存总结文件失败: %w", err)
+	}
+	return nil
+}
+
+// 总结文件中issues列表章节的标题，图表图片章节插入在它之前
+const summaryIssuesHeading = "## Issues列表\n\n"
+
+// AI分析总结文件名
+func summaryFileName(cfg *Config) string {
+	if cfg.SummaryFile == "" {
+		return "summary.md"
+	}
+	return cfg.SummaryFile
+}
+
+func issueLabelsString(issue *github.Issue) string {
+	var labels []string
+	for _, label := range issue.Labels {
+		labels = append(labels, label.GetName())
+	}
+	return strings.Join(labels, ", ")
+}
+
+// 生成单个issue在提示词中的内容：表格行和描述
+func formatIssueForPrompt(issue *github.Issue, maxTokens int) (row, body string) {
+	row = fmt.Sprintf("| #%d | %s | %s | %s | %s |\n",
+		issue.GetNumber(),
+		issue.GetTitle(),
+		issue.GetState(),
+		issue.GetCreatedAt().Format("2006-01-02"),
+		issueLabelsString(issue))
+
+	// 添加issue描述（如果有），过长的描述截断到单批上限以内
+	if issue.GetBody() != "" {
+		text := truncateToTokens(issue.GetBody(), maxTokens-countTokens(row))
+		body = fmt.Sprintf("\n**Issue #%d 描述**:\n%s\n\n", issue.GetNumber(), text)
+	}
+	return row, body
+}
+
+// 按token数将issues切分为多批，每批包含issues表格和描述
+func chunkIssuesForPrompt(issues []*github.Issue, maxTokens int) []string {
+	const header = "| 编号 | 标题 | 状态 | 创建时间 | 标签 |\n|------|------|------|----------|------|\n"
+	headerTokens := countTokens(header)
+
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+	flush := func() {
+		if currentTokens > 0 {
+			chunks = append(chunks, header+current.String())
+			current.Reset()
+			currentTokens = 0
+		}
+	}
+
+	for _, issue := range issues {
+		row, body := formatIssueForPrompt(issue, maxTokens-headerTokens)
+		tokens := countTokens(row + body)
+		if currentTokens > 0 && headerTokens+currentTokens+tokens > maxTokens {
+			flush()
+		}
+		current.WriteString(row)
+		current.WriteString(body)
+		currentTokens += tokens
+	}
+	flush()
+
+	if len(chunks) == 0 {
+		chunks = append(chunks, header)
+	}
+	return chunks
+}
+
+// 并行发送每批issues的提示词，返回各批的部分总结和因token上限被跳过的批数
+func mapIssueChunks(ctx context.Context, client *aiClient, prompts []string, concurrency int) ([]string, int, error) {
+	if concurrency <= 0 {
+		concurrency = defaultAIConcurrency
+	}
+
+	results := make([]string, len(prompts))
+	errs := make([]error, len(prompts))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	fmt.Printf("issues较多，分为 %d 批进行分析...\n", len(prompts))
+	for i, prompt := range prompts {
+		wg.Add(1)
+		go func(i int, prompt string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = generateText(ctx, client, prompt)
+
+			mu.Lock()
+			done++
+			fmt.Printf("AI分析进度: %d/%d 批\n", done, len(prompts))
+			mu.Unlock()
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	var partials []string
+	skipped := 0
+	for i, err := range errs {
+		if err == nil {
+			partials = append(partials, results[i])
+			continue
+		}
+		if errors.Is(err, errTokenBudgetExceeded) {
+			skipped++
+			continue
+		}
+		return nil, 0, fmt.Errorf("第%d批分析失败: %w", i+1, err)
+	}
+
+	if len(partials) == 0 {
+		return nil, 0, fmt.Errorf("所有批次都因token上限 %d 被跳过", client.budget.limit)
+	}
+	if skipped > 0 {
+		fmt.Printf("警告: 受token上限限制，跳过了 %d 批issues\n", skipped)
+	}
+	return partials, skipped, nil
+}
+
+// 将部分总结合并为最终总结，部分总结过多时逐层合并，最后一轮合并时附加输出格式要求和调用参数
+func reducePartialSummaries(ctx context.Context, client *aiClient, partials []string, maxTokens int, style *reportStyle, outputInstruction string, finalOptions ...llms.CallOption) (string, error) {
+	instruction := fmt.Sprintf("以下是对GitHub仓库issues分批分析得到的多份%s，请将它们合并为一份完整的%s：\n\n", style.Goal, style.Goal)
+	maxTokens -= countTokens(outputInstruction)
+
+	for round := 1; ; round++ {
+		// 按token数将部分总结分组，每组合并为一份
+		var groups [][]string
+		var group []string
+		groupTokens := countTokens(instruction)
+		for _, partial := range partials {
+			// 每份总结不超过上限的一半，保证每组至少能合并两份
+			partial = truncateToTokens(partial, (maxTokens-countTokens(instruction))/2)
+			tokens := countTokens(partial)
+			if len(group) > 0 && groupTokens+tokens > maxTokens {
+				groups = append(groups, group)
+				group, groupTokens = nil, countTokens(instruction)
+			}
+			group = append(group, partial)
+			groupTokens += tokens
+		}
+		groups = append(groups, group)
+
+		// 每组只能放下一份总结时合并不会减少份数，继续请求只会消耗token
+		if len(groups) > 1 && len(groups) >= len(partials) {
+			return "", fmt.Errorf("aiChunkTokens(%d) 过小，无法在一次请求中合并多份总结", maxTokens+countTokens(outputInstruction))
+		}
+
+		fmt.Printf("正在合并部分总结（第%d轮，%d 份合并为 %d 份）...\n", round, len(partials), len(groups))
+		merged := make([]string, 0, len(groups))
+		for _, g := range groups {
+			var prompt strings.Builder
+			prompt.WriteString(instruction)
+			for i, partial := range g {
+				prompt.WriteString(fmt.Sprintf("### 第%d份总结\n\n%s\n\n", i+1, partial))
+			}
+			var options []llms.CallOption
+			if len(groups) == 1 {
+				prompt.WriteString(outputInstruction)
+				options = finalOptions
+			}
+			completion, err := generateText(ctx, client, prompt.String(), options...)
+			if err != nil {
+				return "", fmt.Errorf("合并总结失败: %w", err)
+			}
+			merged = append(merged, completion)
+		}
+
+		if len(merged) == 1 {
+			return merged[0], nil
+		}
+		partials = merged
+	}
 }
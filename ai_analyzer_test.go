@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// 使用mock模型的配置，部分总结和合并请求分别返回固定内容
+func newMockSummaryConfig(t *testing.T, dir string) *Config {
+	t.Helper()
+	fixtures := `{"responses": [
+		{"match": "合并为一份完整的", "response": "MERGED-SUMMARY"},
+		{"match": "批）", "response": "PARTIAL-SUMMARY"}
+	]}`
+	fixturesPath := filepath.Join(dir, "fixtures.json")
+	if err := os.WriteFile(fixturesPath, []byte(fixtures), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &Config{
+		AIProvider:     "mock",
+		AIModel:        "mock",
+		AIChunkTokens:  600,
+		AIConcurrency:  1,
+		MockFixtures:   fixturesPath,
+		MockRecordFile: filepath.Join(dir, "records.jsonl"),
+	}
+}
+
+// 两个描述较长的issues，单批放不下，会被分为两批
+func newLongIssues() []*github.Issue {
+	body := strings.Repeat("alpha beta gamma delta ", 60)
+	created := &github.Timestamp{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	var issues []*github.Issue
+	for i := 1; i <= 2; i++ {
+		issues = append(issues, &github.Issue{
+			Number:    github.Int(i),
+			Title:     github.String("issue title"),
+			State:     github.String("open"),
+			Body:      github.String(body),
+			CreatedAt: created,
+		})
+	}
+	return issues
+}
+
+func readMockRecords(t *testing.T, path string) []mockRecord {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []mockRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		var record mockRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestGenerateAISummaryChunksAndMerges(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	records := readMockRecords(t, cfg.MockRecordFile)
+	if len(records) != 3 {
+		t.Fatalf("请求次数 = %d，期望2批加1次合并", len(records))
+	}
+	for i, record := range records[:2] {
+		if record.Response != "PARTIAL-SUMMARY" {
+			t.Errorf("第%d次请求不是分批请求: %q", i+1, record.Prompt)
+		}
+	}
+	merge := records[2]
+	if merge.Response != "MERGED-SUMMARY" || strings.Count(merge.Prompt, "PARTIAL-SUMMARY") != 2 {
+		t.Errorf("合并请求应包含两份部分总结: %q", merge.Prompt)
+	}
+	for i, record := range records {
+		if record.Seq <= 0 || (i > 0 && record.Seq != records[i-1].Seq+1) {
+			t.Errorf("记录的Seq不连续: %v", records)
+			break
+		}
+	}
+
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"MERGED-SUMMARY", "| [#1]", "| [#2]"} {
+		if !strings.Contains(string(summary), want) {
+			t.Errorf("总结文件缺少 %q", want)
+		}
+	}
+	if strings.Contains(string(summary), "未参与分析") {
+		t.Errorf("没有批次被跳过，总结中不应有提示")
+	}
+}
+
+func TestGenerateAISummarySkipsChunksOverBudget(t *testing.T) {
+	// 先不限制token，得到每次请求的token数
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err != nil {
+		t.Fatal(err)
+	}
+	records := readMockRecords(t, cfg.MockRecordFile)
+	if len(records) != 3 {
+		t.Fatalf("请求次数 = %d，期望3次", len(records))
+	}
+	batch := max(countTokens(records[0].Prompt), countTokens(records[1].Prompt))
+	completion := countTokens("PARTIAL-SUMMARY")
+	merge := countTokens(records[2].Prompt)
+
+	// 上限只够一批和合并请求，第二批被跳过
+	runTokenBudget = &tokenBudget{}
+	dir = t.TempDir()
+	cfg = newMockSummaryConfig(t, dir)
+	cfg.AIMaxTotalTokens = batch + completion + merge
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(summary), "共有 1/2 批issues未参与分析") {
+		t.Errorf("总结中缺少跳过批次的提示:\n%s", summary)
+	}
+	if !strings.Contains(string(summary), "MERGED-SUMMARY") {
+		t.Errorf("剩余的批次应合并为最终总结:\n%s", summary)
+	}
+}
+
+func TestGenerateAISummaryFailsWhenAllChunksOverBudget(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	cfg.AIMaxTotalTokens = 10
+
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err == nil {
+		t.Fatal("所有批次都超出token上限时应返回错误")
+	}
+}
+
+func TestGenerateAISummaryRedactsIssueTitles(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	cfg.RedactExport = true
+	issues := newLongIssues()
+	issues[0].Title = github.String("login fails for alice@example.com")
+
+	if err := generateAISummary(issues, dir, "owner/repo", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := os.ReadFile(filepath.Join(dir, "summary.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(summary), "alice@example.com") {
+		t.Errorf("总结文件中的标题未脱敏:\n%s", summary)
+	}
+	if !strings.Contains(string(summary), "login fails for [REDACTED:email]") {
+		t.Errorf("总结文件缺少脱敏后的标题:\n%s", summary)
+	}
+}
+
+func TestGenerateAISummaryStreamKeepsSummaryOnFailure(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	cfg.AIStream = true
+	cfg.AIMaxTotalTokens = 10
+
+	summaryPath := filepath.Join(dir, "summary.md")
+	if err := os.WriteFile(summaryPath, []byte("previous summary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err == nil {
+		t.Fatal("所有批次都超出token上限时应返回错误")
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(summary) != "previous summary" {
+		t.Errorf("生成失败时原有的总结文件被修改: %q", summary)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) > 0 {
+		t.Errorf("临时文件未删除: %v", tmp)
+	}
+}
+
+func TestGenerateAISummaryStreamReplacesSummary(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	cfg.AIStream = true
+
+	summaryPath := filepath.Join(dir, "summary.md")
+	if err := os.WriteFile(summaryPath, []byte("previous summary"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(summary), "MERGED-SUMMARY") || strings.Contains(string(summary), "正在生成") {
+		t.Errorf("总结文件不是完整的报告:\n%s", summary)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) > 0 {
+		t.Errorf("临时文件未删除: %v", tmp)
+	}
+}
+
+func TestReducePartialSummariesRejectsTooSmallChunks(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	client, err := newAIClient(cfg, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	style, err := getReportStyle("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	partials := []string{"first partial summary", "second partial summary", "third partial summary"}
+	if _, err := reducePartialSummaries(context.Background(), client, partials, 20, style, ""); err == nil {
+		t.Fatal("aiChunkTokens过小时应返回错误")
+	}
+	if _, err := os.Stat(cfg.MockRecordFile); !os.IsNotExist(err) {
+		t.Errorf("合并无法减少份数时不应发送请求")
+	}
+}
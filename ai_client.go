@@ -0,0 +1,429 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"github.com/tmc/langchaingo/llms"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// AI应答缓存目录名，位于输出目录下
+	aiCacheDir = ".ai_cache"
+
+	// 未配置时单次AI请求的超时时间
+	defaultAITimeout = 2 * time.Minute
+
+	// 未配置时AI请求失败后的重试次数
+	defaultAIMaxRetries = 3
+
+	// 重试等待时间的初始值和上限，每次重试等待时间翻倍
+	aiRetryBaseDelay = time.Second
+	aiRetryMaxDelay  = 30 * time.Second
+)
+
+// 匹配错误信息中限流和服务端错误的状态码：langchaingo的OpenAI和Anthropic客户端返回
+// "API returned unexpected status code: 429"，Ollama返回HTTP状态行如 "503 Service Unavailable"
+var retryableStatusRegex = regexp.MustCompile(`status code: (429|5\d\d)\b|(?:^|: )(429|5\d\d) [a-z]`)
+
+// 超出token上限时返回的错误
+var errTokenBudgetExceeded = errors.New("超出token上限")
+
+// token用量上限，超出后拒绝新的请求
+type tokenBudget struct {
+	mu    sync.Mutex
+	limit int
+	used  int
+}
+
+// 本次运行所有AI请求共享的token上限
+var runTokenBudget = &tokenBudget{}
+
+// 设置token上限，0表示不限制
+func (b *tokenBudget) setLimit(limit int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limit = limit
+}
+
+// 为即将发送的提示词预留token，超出上限时返回错误
+func (b *tokenBudget) reserve(tokens int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.limit > 0 && b.used+tokens > b.limit {
+		return fmt.Errorf("%w %d（已使用 %d，本次需要 %d）", errTokenBudgetExceeded, b.limit, b.used, tokens)
+	}
+	b.used += tokens
+	return nil
+}
+
+// 记录模型返回内容消耗的token
+func (b *tokenBudget) add(tokens int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.used += tokens
+}
+
+// 本次运行的AI用量统计
+type aiUsage struct {
+	mu               sync.Mutex
+	Requests         int
+	CacheHits        int
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// 本次运行所有AI请求的用量
+var runAIUsage = &aiUsage{}
+
+func (u *aiUsage) record(promptTokens, completionTokens int, cached bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.Requests++
+	if cached {
+		u.CacheHits++
+		return
+	}
+	u.PromptTokens += promptTokens
+	u.CompletionTokens += completionTokens
+}
+
+// 生成用量说明，配置了单价时包含预估费用
+func (u *aiUsage) String(cfg *Config) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	s := fmt.Sprintf("AI请求 %d 次（缓存命中 %d 次），输入 %d tokens，输出 %d tokens",
+		u.Requests, u.CacheHits, u.PromptTokens, u.CompletionTokens)
+	if cfg.AIPromptPrice > 0 || cfg.AICompletionPrice > 0 {
+		cost := float64(u.PromptTokens)*cfg.AIPromptPrice/1e6 + float64(u.CompletionTokens)*cfg.AICompletionPrice/1e6
+		s += fmt.Sprintf("，预估费用 %.4f", cost)
+	}
+	return s
+}
+
+// 缓存的AI应答
+type aiCacheEntry struct {
+	Model            string `json:"model"`
+	Completion       string `json:"completion"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+}
+
+// 对模型的封装：提示词脱敏，按提示词内容缓存应答，超时和失败时重试，统计用量并限制token总数
+type aiClient struct {
+	llm        llms.Model
+	model      string
+	cacheDir   string
+	timeout    time.Duration
+	maxRetries int
+	redactor   *redactor
+	budget     *tokenBudget
+	usage      *aiUsage
+}
+
+// 创建AI客户端，启用缓存时应答缓存在输出目录下
+func newAIClient(cfg *Config, outputDir string) (*aiClient, error) {
+	llm, err := newLLM(cfg)
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &aiClient{
+		llm:        llm,
+		model:      aiProvider(cfg) + "/" + cfg.AIModel,
+		timeout:    time.Duration(cfg.AITimeout) * time.Second,
+		maxRetries: cfg.AIMaxRetries,
+		redactor:   redactor,
+		budget:     runTokenBudget,
+		usage:      runAIUsage,
+	}
+	runTokenBudget.setLimit(cfg.AIMaxTotalTokens)
+	if c.timeout <= 0 {
+		c.timeout = defaultAITimeout
+	}
+	if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+	if cfg.AICacheEnable && outputDir != "" {
+		c.cacheDir = filepath.Join(outputDir, aiCacheDir)
+		if err := os.MkdirAll(c.cacheDir, 0755); err != nil {
+			return nil, fmt.Errorf("创建AI缓存目录失败: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// GenerateContent 实现llms.Model接口，发送前总是对提示词脱敏
+func (c *aiClient) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	messages = c.redactor.redactMessages(messages)
+	prompt := messagesText(messages)
+	key := c.cacheKey(messages, options)
+
+	if entry := c.loadCache(key); entry != nil {
+		c.usage.record(entry.PromptTokens, entry.CompletionTokens, true)
+		// 缓存命中时一次性输出完整内容，保持流式输出的行为一致
+		if streaming := callOptions(options).StreamingFunc; streaming != nil {
+			if err := streaming(ctx, []byte(entry.Completion)); err != nil {
+				return nil, err
+			}
+		}
+		return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: entry.Completion}}}, nil
+	}
+
+	if err := c.budget.reserve(countTokens(prompt)); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.generateWithRetry(ctx, messages, options)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return resp, nil
+	}
+
+	// 优先使用服务端返回的用量，没有时用tiktoken估算
+	choice := resp.Choices[0]
+	promptTokens, completionTokens := usageFromGenerationInfo(choice.GenerationInfo)
+	if promptTokens == 0 {
+		promptTokens = countTokens(prompt)
+	}
+	if completionTokens == 0 {
+		completionTokens = countTokens(choice.Content)
+	}
+	c.budget.add(completionTokens)
+	c.usage.record(promptTokens, completionTokens, false)
+
+	c.saveCache(key, &aiCacheEntry{
+		Model:            c.model,
+		Completion:       choice.Content,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+	})
+	return resp, nil
+}
+
+// 发送请求，遇到限流、服务端错误或超时时按指数退避重试，已经开始流式输出后不再重试
+func (c *aiClient) generateWithRetry(ctx context.Context, messages []llms.MessageContent, options []llms.CallOption) (*llms.ContentResponse, error) {
+	streamed := false
+	if streaming := callOptions(options).StreamingFunc; streaming != nil {
+		options = append(options[:len(options):len(options)], llms.WithStreamingFunc(func(ctx context.Context, chunk []byte) error {
+			streamed = true
+			return streaming(ctx, chunk)
+		}))
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		resp, err := c.llm.GenerateContent(attemptCtx, messages, options...)
+		timedOut := attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+		cancel()
+		if err == nil {
+			return resp, nil
+		}
+		if timedOut {
+			err = fmt.Errorf("AI请求超时（%s）: %w", c.timeout, err)
+		}
+
+		if attempt >= c.maxRetries || streamed || ctx.Err() != nil || !(timedOut || isRetryableAIError(err)) {
+			return nil, err
+		}
+
+		delay := retryDelay(attempt)
+		fmt.Printf("AI请求失败，%s后进行第%d次重试: %v\n", delay.Round(time.Millisecond), attempt+1, err)
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// 限流和服务端错误的HTTP状态码可以重试
+func isRetryableStatusCode(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// 判断是否为可以重试的错误：限流或服务端错误
+func isRetryableAIError(err error) bool {
+	// Google AI返回带有状态码的错误类型
+	var googleErr *googleapi.Error
+	if errors.As(err, &googleErr) {
+		return isRetryableStatusCode(googleErr.Code)
+	}
+	var apiErr *apierror.APIError
+	if errors.As(err, &apiErr) {
+		if code := apiErr.HTTPCode(); code > 0 {
+			return isRetryableStatusCode(code)
+		}
+		switch apiErr.GRPCStatus().Code() {
+		case codes.ResourceExhausted, codes.Unavailable, codes.Internal, codes.DeadlineExceeded:
+			return true
+		}
+		return false
+	}
+
+	msg := strings.ToLower(err.Error())
+	return retryableStatusRegex.MatchString(msg) ||
+		strings.Contains(msg, "rate limit") ||
+		strings.Contains(msg, "too many requests") ||
+		strings.Contains(msg, "overloaded")
+}
+
+// 第attempt次重试前的等待时间，按指数增长并加入随机抖动
+func retryDelay(attempt int) time.Duration {
+	delay := aiRetryBaseDelay << attempt
+	if delay <= 0 || delay > aiRetryMaxDelay {
+		delay = aiRetryMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// 将流式输出的内容同时写到控制台和文件
+func streamOutput(w io.Writer) llms.CallOption {
+	return llms.WithStreamingFunc(func(_ context.Context, chunk []byte) error {
+		os.Stdout.Write(chunk)
+		if w == nil {
+			return nil
+		}
+		_, err := w.Write(chunk)
+		return err
+	})
+}
+
+// Call 实现llms.Model接口
+func (c *aiClient) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, c, prompt, options...)
+}
+
+// 缓存键：模型、消息内容和调用参数的哈希
+func (c *aiClient) cacheKey(messages []llms.MessageContent, options []llms.CallOption) string {
+	opts := callOptions(options)
+
+	h := sha256.New()
+	h.Write([]byte(c.model))
+	for _, message := range messages {
+		h.Write([]byte{0})
+		h.Write([]byte(message.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(messagesText([]llms.MessageContent{message})))
+	}
+	if data, err := json.Marshal(opts); err == nil {
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *aiClient) loadCache(key string) *aiCacheEntry {
+	if c.cacheDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(c.cacheDir, key+".json"))
+	if err != nil {
+		return nil
+	}
+	var entry aiCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	return &entry
+}
+
+func (c *aiClient) saveCache(key string, entry *aiCacheEntry) {
+	if c.cacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err == nil {
+		err = os.WriteFile(filepath.Join(c.cacheDir, key+".json"), data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("提示: 保存AI缓存失败: %v\n", err)
+	}
+}
+
+// 发送单个提示词，返回模型生成的文本
+func generateText(ctx context.Context, llm llms.Model, prompt string, options ...llms.CallOption) (string, error) {
+	completion, err := llms.GenerateFromSinglePrompt(ctx, llm, prompt, options...)
+	if err != nil {
+		if errors.Is(err, errTokenBudgetExceeded) {
+			return "", err
+		}
+		return "", fmt.Errorf("发送AI请求失败: %w", err)
+	}
+	return completion, nil
+}
+
+// 合并调用参数
+func callOptions(options []llms.CallOption) llms.CallOptions {
+	opts := llms.CallOptions{}
+	for _, opt := range options {
+		opt(&opts)
+	}
+	return opts
+}
+
+// 拼接消息中的文本内容
+func messagesText(messages []llms.MessageContent) string {
+	var sb strings.Builder
+	for _, message := range messages {
+		for _, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				sb.WriteString(text.Text)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// 从各提供方返回的元数据中读取token用量
+func usageFromGenerationInfo(info map[string]any) (promptTokens, completionTokens int) {
+	for _, key := range []string{"PromptTokens", "InputTokens", "input_tokens"} {
+		if v := anyToInt(info[key]); v > 0 {
+			promptTokens = v
+			break
+		}
+	}
+	for _, key := range []string{"CompletionTokens", "OutputTokens", "output_tokens"} {
+		if v := anyToInt(info[key]); v > 0 {
+			completionTokens = v
+			break
+		}
+	}
+	return promptTokens, completionTokens
+}
+
+func anyToInt(v any) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int32:
+		return int(n)
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
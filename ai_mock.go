@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// mock向量的维度
+const mockEmbeddingDim = 64
+
+// mock模型的应答脚本
+type mockFixtures struct {
+	// 按顺序匹配的应答，第一个匹配提示词的应答生效
+	Responses []mockFixture `json:"responses"`
+
+	// 没有匹配的应答时返回的内容，为空时原样返回提示词
+	Default string `json:"default"`
+}
+
+type mockFixture struct {
+	// 匹配提示词的正则表达式，为空时匹配所有提示词
+	Match    string `json:"match"`
+	Response string `json:"response"`
+
+	regex *regexp.Regexp
+}
+
+// 离线测试用的模型，根据脚本返回固定内容，并记录收到的提示词
+type mockLLM struct {
+	fixtures   *mockFixtures
+	recordPath string
+}
+
+// 本次运行所有mock模型共享的调用序号，多个客户端写入同一记录文件时Seq不重复
+var mockCalls struct {
+	mu  sync.Mutex
+	seq int
+}
+
+// mock模型记录的一次调用
+type mockRecord struct {
+	Seq      int    `json:"seq"`
+	Prompt   string `json:"prompt"`
+	Response string `json:"response"`
+}
+
+// 创建mock模型，fixturesPath为空时所有请求都原样返回提示词
+func newMockLLM(fixturesPath, recordPath string) (*mockLLM, error) {
+	fixtures := &mockFixtures{}
+	if fixturesPath != "" {
+		data, err := os.ReadFile(fixturesPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取mock应答脚本失败: %w", err)
+		}
+		if err := json.Unmarshal(data, fixtures); err != nil {
+			return nil, fmt.Errorf("解析mock应答脚本失败: %w", err)
+		}
+	}
+
+	for i := range fixtures.Responses {
+		regex, err := regexp.Compile(fixtures.Responses[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("mock应答脚本第%d项的正则表达式无效: %w", i+1, err)
+		}
+		fixtures.Responses[i].regex = regex
+	}
+
+	return &mockLLM{fixtures: fixtures, recordPath: recordPath}, nil
+}
+
+// GenerateContent 实现llms.Model接口
+func (m *mockLLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	prompt := messagesText(messages)
+	response := m.respond(prompt)
+	if err := m.record(prompt, response); err != nil {
+		return nil, err
+	}
+
+	if streaming := callOptions(options).StreamingFunc; streaming != nil {
+		if err := streaming(ctx, []byte(response)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &llms.ContentResponse{
+		Choices: []*llms.ContentChoice{{
+			Content:    response,
+			StopReason: "stop",
+			GenerationInfo: map[string]any{
+				"PromptTokens":     countTokens(prompt),
+				"CompletionTokens": countTokens(response),
+			},
+		}},
+	}, nil
+}
+
+// Call 实现llms.Model接口
+func (m *mockLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// CreateEmbedding 实现embeddings.EmbedderClient接口，按词的哈希生成确定的向量
+func (m *mockLLM) CreateEmbedding(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, mockEmbeddingDim)
+		for _, word := range strings.Fields(strings.ToLower(text)) {
+			sum := sha256.Sum256([]byte(word))
+			vector[binary.BigEndian.Uint32(sum[:4])%mockEmbeddingDim]++
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v) * float64(v)
+		}
+		if norm > 0 {
+			for j := range vector {
+				vector[j] = float32(float64(vector[j]) / math.Sqrt(norm))
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// 按脚本查找应答
+func (m *mockLLM) respond(prompt string) string {
+	for _, fixture := range m.fixtures.Responses {
+		if fixture.regex.MatchString(prompt) {
+			return fixture.Response
+		}
+	}
+	if m.fixtures.Default != "" {
+		return m.fixtures.Default
+	}
+	return prompt
+}
+
+// 记录收到的提示词，配置了记录文件时追加写入JSON Lines
+func (m *mockLLM) record(prompt, response string) error {
+	mockCalls.mu.Lock()
+	defer mockCalls.mu.Unlock()
+
+	mockCalls.seq++
+	if m.recordPath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(mockRecord{Seq: mockCalls.seq, Prompt: prompt, Response: response})
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(m.recordPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("记录mock提示词失败: %w", err)
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
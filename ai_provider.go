@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/anthropic"
+	"github.com/tmc/langchaingo/llms/googleai"
+	"github.com/tmc/langchaingo/llms/ollama"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+// 支持的AI服务提供方
+const (
+	AIProviderOpenAI    = "openai"
+	AIProviderAzure     = "azure"
+	AIProviderOllama    = "ollama"
+	AIProviderAnthropic = "anthropic"
+	AIProviderGoogleAI  = "googleai"
+	AIProviderMock      = "mock"
+)
+
+// 获取AI服务提供方，未配置时使用OpenAI兼容接口
+func aiProvider(cfg *Config) string {
+	if cfg.AIProvider == "" {
+		return AIProviderOpenAI
+	}
+	return cfg.AIProvider
+}
+
+// 检查AI配置是否可用，本地运行的Ollama和离线测试用的mock不需要token
+func validateAIConfig(cfg *Config) error {
+	switch aiProvider(cfg) {
+	case AIProviderOllama, AIProviderMock:
+		return nil
+	case AIProviderOpenAI, AIProviderAzure, AIProviderAnthropic, AIProviderGoogleAI:
+		if cfg.AIToken == "" {
+			return fmt.Errorf("未提供AI Token")
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的AI服务提供方: %s", cfg.AIProvider)
+	}
+}
+
+// 创建AI客户端
+func newLLM(cfg *Config) (llms.Model, error) {
+	if err := validateAIConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	var llm llms.Model
+	var err error
+	switch aiProvider(cfg) {
+	case AIProviderOpenAI:
+		llm, err = openai.New(openai.WithToken(cfg.AIToken), openai.WithModel(cfg.AIModel), openai.WithBaseURL(cfg.AIBaseURL))
+	case AIProviderAzure:
+		llm, err = newAzureOpenAI(cfg, openai.WithModel(cfg.AIModel))
+	case AIProviderOllama:
+		llm, err = ollama.New(ollama.WithModel(cfg.AIModel), ollama.WithServerURL(ollamaServerURL(cfg)))
+	case AIProviderAnthropic:
+		opts := []anthropic.Option{anthropic.WithToken(cfg.AIToken), anthropic.WithModel(cfg.AIModel)}
+		if cfg.AnthropicBaseURL != "" {
+			opts = append(opts, anthropic.WithBaseURL(cfg.AnthropicBaseURL))
+		}
+		llm, err = anthropic.New(opts...)
+	case AIProviderGoogleAI:
+		llm, err = googleai.New(context.Background(), googleai.WithAPIKey(cfg.AIToken), googleai.WithDefaultModel(cfg.AIModel))
+	case AIProviderMock:
+		llm, err = newMockLLM(cfg.MockFixtures, cfg.MockRecordFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建AI客户端失败: %w", err)
+	}
+	return llm, nil
+}
+
+// 创建向量化客户端，使用embeddingModel指定的模型，发送前对文本脱敏
+func newEmbedder(cfg *Config) (embeddings.Embedder, error) {
+	if err := validateAIConfig(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.EmbeddingModel == "" {
+		return nil, fmt.Errorf("未配置embeddingModel")
+	}
+
+	var client embeddings.EmbedderClient
+	var err error
+	switch aiProvider(cfg) {
+	case AIProviderOpenAI:
+		baseURL := cfg.EmbeddingBaseURL
+		if baseURL == "" {
+			baseURL = cfg.AIBaseURL
+		}
+		client, err = openai.New(openai.WithToken(cfg.AIToken), openai.WithEmbeddingModel(cfg.EmbeddingModel), openai.WithBaseURL(baseURL))
+	case AIProviderAzure:
+		client, err = newAzureOpenAI(cfg, openai.WithEmbeddingModel(cfg.EmbeddingModel))
+	case AIProviderOllama:
+		client, err = ollama.New(ollama.WithModel(cfg.EmbeddingModel), ollama.WithServerURL(ollamaServerURL(cfg)))
+	case AIProviderGoogleAI:
+		client, err = googleai.New(context.Background(), googleai.WithAPIKey(cfg.AIToken), googleai.WithDefaultEmbeddingModel(cfg.EmbeddingModel))
+	case AIProviderMock:
+		client, err = newMockLLM(cfg.MockFixtures, cfg.MockRecordFile)
+	default:
+		return nil, fmt.Errorf("AI服务提供方 %s 不支持向量化", aiProvider(cfg))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("创建AI客户端失败: %w", err)
+	}
+	embedder, err := embeddings.NewEmbedder(client, embeddings.WithBatchSize(embeddingBatchSize))
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := newRedactor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &redactingEmbedder{Embedder: embedder, redactor: redactor}, nil
+}
+
+// 创建Azure OpenAI客户端，模型名对应Azure中的部署名称
+func newAzureOpenAI(cfg *Config, opts ...openai.Option) (*openai.LLM, error) {
+	if cfg.AzureEndpoint == "" {
+		return nil, fmt.Errorf("未配置azureEndpoint")
+	}
+
+	apiVersion := cfg.AzureAPIVersion
+	if apiVersion == "" {
+		apiVersion = openai.DefaultAPIVersion
+	}
+	return openai.New(append([]openai.Option{
+		openai.WithAPIType(openai.APITypeAzure),
+		openai.WithToken(cfg.AIToken),
+		openai.WithBaseURL(cfg.AzureEndpoint),
+		openai.WithAPIVersion(apiVersion),
+	}, opts...)...)
+}
+
+// Ollama服务地址，未配置时使用本地默认地址
+func ollamaServerURL(cfg *Config) string {
+	if cfg.OllamaServerURL == "" {
+		return "http://localhost:11434"
+	}
+	return cfg.OllamaServerURL
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// 默认检索的issue数量
+	defaultAskTopK = 8
+
+	// 默认提供给模型的issues内容token上限
+	defaultAskContextTokens = 6000
+
+	// 对话模式中保留的历史轮数
+	chatHistoryTurns = 3
+
+	// 每轮历史回答在提示词中的token上限
+	chatHistoryAnswerTokens = 500
+)
+
+// 匹配回答中引用的issue编号，如 [#12]
+var askCitationRegex = regexp.MustCompile(`\[#(\d+)\]`)
+
+// 问答的一轮对话
+type chatTurn struct {
+	Question string
+	Answer   string
+}
+
+// 问答使用的检索和生成参数
+type asker struct {
+	cfg           *Config
+	dir           string
+	topK          int
+	contextTokens int
+	llm           *aiClient
+
+	// 开始问答前加载一次，对话中的每个问题共用
+	issues   []*exportedIssue
+	embedder embeddings.Embedder
+	store    *embeddingStore
+	index    bleve.Index
+}
+
+// ask子命令：根据已导出的issues回答一个问题，并注明引用的issues
+func runAsk(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("ask", flag.ExitOnError)
+	a := registerAskFlags(fs, cfg)
+	output := fs.String("o", "", "将回答保存为Markdown文件，引用链接到对应的issue文件")
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file ask [选项] <问题>")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("未提供问题")
+	}
+	ctx := context.Background()
+	if err := a.init(ctx); err != nil {
+		return err
+	}
+	defer a.close()
+
+	question := strings.Join(fs.Args(), " ")
+	answer, issues, err := a.ask(ctx, question, nil)
+	if err != nil {
+		return err
+	}
+	printAskReferences(answer, issues)
+
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(formatAskMarkdown(question, answer, issues, filepath.Dir(*output))), 0644); err != nil {
+			return fmt.Errorf("保存回答失败: %w", err)
+		}
+		fmt.Printf("回答已保存到: %s\n", *output)
+	}
+	return nil
+}
+
+// chat子命令：基于已导出的issues进行多轮问答，输入 exit 退出
+func runChat(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("chat", flag.ExitOnError)
+	a := registerAskFlags(fs, cfg)
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file chat [选项]")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ctx := context.Background()
+	if err := a.init(ctx); err != nil {
+		return err
+	}
+	defer a.close()
+
+	fmt.Println("请输入问题，输入 exit 退出")
+	scanner := bufio.NewScanner(os.Stdin)
+	var history []chatTurn
+	for {
+		fmt.Print("\n> ")
+		if !scanner.Scan() {
+			break
+		}
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" {
+			continue
+		}
+		if question == "exit" || question == "quit" {
+			break
+		}
+
+		answer, issues, err := a.ask(ctx, question, history)
+		if err != nil {
+			fmt.Printf("回答失败: %v\n", err)
+			continue
+		}
+		printAskReferences(answer, issues)
+
+		history = append(history, chatTurn{Question: question, Answer: answer})
+		if len(history) > chatHistoryTurns {
+			history = history[len(history)-chatHistoryTurns:]
+		}
+	}
+	if runAIUsage.Requests > 0 {
+		fmt.Println(runAIUsage.String(cfg))
+	}
+	return scanner.Err()
+}
+
+// 注册ask和chat共用的参数
+func registerAskFlags(fs *flag.FlagSet, cfg *Config) *asker {
+	a := &asker{cfg: cfg}
+	fs.StringVar(&a.dir, "dir", cfg.OutputDir, "已导出issues的目录")
+	fs.IntVar(&a.topK, "k", defaultAskTopK, "每个问题检索的issue数量")
+	fs.IntVar(&a.contextTokens, "contextTokens", defaultAskContextTokens, "提供给模型的issues内容token上限")
+	return a
+}
+
+// 创建AI客户端并加载已导出的issues，配置了向量模型时同步向量
+func (a *asker) init(ctx context.Context) error {
+	if a.dir == "" {
+		return fmt.Errorf("未指定已导出issues的目录，请使用 -dir 参数")
+	}
+	if a.topK <= 0 {
+		a.topK = defaultAskTopK
+	}
+	if a.contextTokens <= 0 {
+		a.contextTokens = defaultAskContextTokens
+	}
+
+	var err error
+	if a.llm, err = newAIClient(a.cfg, a.dir); err != nil {
+		return err
+	}
+
+	// 向量同步失败时改用全文检索
+	if a.cfg.EmbeddingModel != "" {
+		err := a.loadEmbeddings(ctx)
+		if err == nil {
+			return nil
+		}
+		fmt.Printf("提示: 语义检索不可用，改用全文检索: %v\n", err)
+	}
+	a.issues, err = loadExportedIssues(a.dir)
+	return err
+}
+
+func (a *asker) loadEmbeddings(ctx context.Context) error {
+	embedder, err := newEmbedder(a.cfg)
+	if err != nil {
+		return err
+	}
+	issues, store, err := syncEmbeddings(ctx, embedder, a.cfg.EmbeddingModel, a.dir)
+	if err != nil {
+		return err
+	}
+	a.issues, a.embedder, a.store = issues, embedder, store
+	return nil
+}
+
+// 关闭全文检索索引
+func (a *asker) close() {
+	if a.index != nil {
+		a.index.Close()
+	}
+}
+
+// 检索与问题相关的issues并生成回答
+func (a *asker) ask(ctx context.Context, question string, history []chatTurn) (string, []*exportedIssue, error) {
+	// 对话中的追问通常省略主语，检索时带上上一轮的问题
+	query := question
+	if len(history) > 0 {
+		query = history[len(history)-1].Question + " " + question
+	}
+	issues, err := a.retrieve(ctx, query)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(issues) == 0 {
+		return "", nil, fmt.Errorf("没有找到与问题相关的issues")
+	}
+
+	var options []llms.CallOption
+	if a.cfg.AIStream {
+		options = append(options, streamOutput(nil))
+	}
+	fmt.Printf("已检索到 %d 个相关issues，正在生成回答...\n\n", len(issues))
+	answer, err := generateText(ctx, a.llm, buildAskPrompt(question, issues, history, a.contextTokens), options...)
+	if err != nil {
+		return "", nil, err
+	}
+	if a.cfg.AIStream {
+		fmt.Println()
+	} else {
+		fmt.Println(answer)
+	}
+	return answer, issues, nil
+}
+
+// 检索相关issues：配置了向量模型时按语义相似度，否则使用全文检索
+func (a *asker) retrieve(ctx context.Context, query string) ([]*exportedIssue, error) {
+	if a.store != nil {
+		issues, err := a.retrieveByEmbedding(ctx, query)
+		if err == nil {
+			return issues, nil
+		}
+		fmt.Printf("提示: 语义检索失败，改用全文检索: %v\n", err)
+	}
+	return a.retrieveByFullText(query)
+}
+
+func (a *asker) retrieveByEmbedding(ctx context.Context, query string) ([]*exportedIssue, error) {
+	vector, err := a.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("向量化问题失败: %w", err)
+	}
+
+	results := rankSimilarIssues(a.issues, a.store, vector, 0, 0)
+	if len(results) > a.topK {
+		results = results[:a.topK]
+	}
+	matched := make([]*exportedIssue, len(results))
+	for i, result := range results {
+		matched[i] = result.Issue
+	}
+	return matched, nil
+}
+
+// 全文检索，索引在第一次检索时打开
+func (a *asker) retrieveByFullText(query string) ([]*exportedIssue, error) {
+	if a.index == nil {
+		index, err := openSearchIndex(a.dir, false)
+		if err != nil {
+			return nil, err
+		}
+		a.index = index
+	}
+
+	req := bleve.NewSearchRequestOptions(buildSearchQuery(query), a.topK, 0, false)
+	req.Fields = []string{"number"}
+	result, err := a.index.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("检索失败: %w", err)
+	}
+
+	byNumber := make(map[int]*exportedIssue, len(a.issues))
+	for _, issue := range a.issues {
+		byNumber[issue.Number] = issue
+	}
+
+	var matched []*exportedIssue
+	for _, hit := range result.Hits {
+		number, _ := hit.Fields["number"].(float64)
+		if issue := byNumber[int(number)]; issue != nil {
+			matched = append(matched, issue)
+		}
+	}
+	return matched, nil
+}
+
+// 构建问答提示词，每个issue平均分配token上限
+func buildAskPrompt(question string, issues []*exportedIssue, history []chatTurn, contextTokens int) string {
+	var sb strings.Builder
+	sb.WriteString("你是一个熟悉该项目GitHub issues的助手。请只根据下面提供的issues回答用户的问题，")
+	sb.WriteString("在引用某个issue的内容时用 [#编号] 标注来源；如果提供的issues不足以回答问题，请直接说明。\n\n")
+
+	sb.WriteString("## 相关issues\n\n")
+	perIssue := contextTokens / len(issues)
+	for _, issue := range issues {
+		var text strings.Builder
+		text.WriteString(fmt.Sprintf("### [#%d] %s\n", issue.Number, issue.Title))
+		text.WriteString(fmt.Sprintf("状态: %s", issue.State))
+		if len(issue.Labels) > 0 {
+			text.WriteString(fmt.Sprintf("，标签: %s", strings.Join(issue.Labels, ", ")))
+		}
+		text.WriteString("\n\n")
+		text.WriteString(issue.Body)
+		for _, comment := range issue.Comments {
+			text.WriteString(fmt.Sprintf("\n\n@%s: %s", comment.Author, comment.Body))
+		}
+		sb.WriteString(truncateToTokens(text.String(), perIssue))
+		sb.WriteString("\n\n")
+	}
+
+	if len(history) > 0 {
+		sb.WriteString("## 之前的对话\n\n")
+		for _, turn := range history {
+			sb.WriteString(fmt.Sprintf("问: %s\n答: %s\n\n", turn.Question, truncateToTokens(turn.Answer, chatHistoryAnswerTokens)))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("## 问题\n\n%s\n", question))
+	return sb.String()
+}
+
+// 回答中引用的issues，没有明确引用时返回所有检索到的issues
+func citedIssues(answer string, issues []*exportedIssue) []*exportedIssue {
+	cited := make(map[int]bool)
+	for _, m := range askCitationRegex.FindAllStringSubmatch(answer, -1) {
+		number, _ := strconv.Atoi(m[1])
+		cited[number] = true
+	}
+
+	var result []*exportedIssue
+	for _, issue := range issues {
+		if cited[issue.Number] {
+			result = append(result, issue)
+		}
+	}
+	if len(result) == 0 {
+		return issues
+	}
+	return result
+}
+
+// 在控制台输出回答引用的issue文件
+func printAskReferences(answer string, issues []*exportedIssue) {
+	fmt.Println("\n参考:")
+	for _, issue := range citedIssues(answer, issues) {
+		fmt.Printf("  [#%d] %s\n        %s\n", issue.Number, issue.Title, issue.Path)
+	}
+}
+
+// 生成Markdown格式的回答，引用链接到相对于baseDir的issue文件
+func formatAskMarkdown(question, answer string, issues []*exportedIssue, baseDir string) string {
+	links := make(map[string]string)
+	for _, issue := range issues {
+		path := issue.Path
+		if rel, err := filepath.Rel(baseDir, issue.Path); err == nil {
+			path = rel
+		}
+		// 文件名可能包含空格，使用尖括号包裹链接地址
+		links[strconv.Itoa(issue.Number)] = "<" + filepath.ToSlash(path) + ">"
+	}
+
+	linked := askCitationRegex.ReplaceAllStringFunc(answer, func(s string) string {
+		number := askCitationRegex.FindStringSubmatch(s)[1]
+		if link, ok := links[number]; ok {
+			return fmt.Sprintf("[#%s](%s)", number, link)
+		}
+		return s
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s\n\n", question))
+	sb.WriteString(linked)
+	sb.WriteString("\n\n## 参考\n\n")
+	for _, issue := range citedIssues(answer, issues) {
+		sb.WriteString(fmt.Sprintf("- [#%d %s](%s)\n", issue.Number, issue.Title, links[strconv.Itoa(issue.Number)]))
+	}
+	return sb.String()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/google/go-github/v57/github"
+)
+
+// 活动日历最多显示最近几年
+const activityCalendarYears = 3
+
+// 热力图纵轴的星期，从周一开始
+var activityWeekdays = []string{"周一", "周二", "周三", "周四", "周五", "周六", "周日"}
+
+// 生成活动时间分布图：按星期和小时统计的热力图，以及每年的活动日历
+// 统计issues和评论的创建时间，comments为nil时只统计issues
+func generateActivityChart(c *chartContext) error {
+	include := func(user *github.User) bool {
+		return !(c.cfg.ChartExcludeBots && isBotUser(user))
+	}
+
+	var times []time.Time
+	for _, issue := range c.issues {
+		if include(issue.GetUser()) {
+			times = append(times, issue.GetCreatedAt().In(c.location))
+		}
+	}
+	for _, issueComments := range c.comments {
+		for _, comment := range issueComments {
+			if include(comment.GetUser()) {
+				times = append(times, comment.GetCreatedAt().In(c.location))
+			}
+		}
+	}
+	if len(times) == 0 {
+		return fmt.Errorf("%w: 没有issues和评论", errChartSkipped)
+	}
+
+	source := "issues和评论"
+	if c.comments == nil {
+		source = "issues（未下载评论）"
+	}
+	subtitle := fmt.Sprintf("按%s的创建时间统计，共 %d 条，时区 %s", source, len(times), c.location)
+
+	items := []components.Charter{newWeekdayHourHeatMap(c.style, times, subtitle)}
+	items = append(items, newActivityCalendars(c.style, times)...)
+	return saveChartPage(filepath.Join(c.dir, "activity_chart.html"), "活动时间分布", items...)
+}
+
+// 星期和小时的热力图，横轴为小时，纵轴为星期
+func newWeekdayHourHeatMap(style chartStyle, times []time.Time, subtitle string) *charts.HeatMap {
+	var counts [7][24]int
+	for _, t := range times {
+		// time.Weekday 从周日开始，转换为从周一开始
+		weekday := (int(t.Weekday()) + 6) % 7
+		counts[weekday][t.Hour()]++
+	}
+
+	hours := make([]string, 24)
+	for h := range hours {
+		hours[h] = fmt.Sprintf("%d时", h)
+	}
+	var data []opts.HeatMapData
+	maxCount := 0
+	for d := range counts {
+		for h, count := range counts[d] {
+			data = append(data, opts.HeatMapData{Value: [3]interface{}{h, d, count}})
+			if count > maxCount {
+				maxCount = count
+			}
+		}
+	}
+
+	heatMap := charts.NewHeatMap()
+	heatMap.SetGlobalOptions(
+		style.initOpts("1000px", "450px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "每周活动时间分布",
+			Subtitle: subtitle,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithGridOpts(opts.Grid{Top: "80px", Bottom: "90px"}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Type:      "category",
+			Data:      hours,
+			SplitArea: &opts.SplitArea{Show: opts.Bool(true)},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Type:      "category",
+			Data:      activityWeekdays,
+			Inverse:   opts.Bool(true),
+			SplitArea: &opts.SplitArea{Show: opts.Bool(true)},
+		}),
+		charts.WithVisualMapOpts(opts.VisualMap{
+			Calculable: opts.Bool(true),
+			Min:        0,
+			Max:        float32(max(maxCount, 1)),
+			Orient:     "horizontal",
+			Left:       "center",
+			Bottom:     "10px",
+			InRange:    &opts.VisualMapInRange{Color: []string{"#ebedf0", "#9be9a8", "#40c463", "#216e39"}},
+		}),
+	)
+	heatMap.SetXAxis(hours).
+		AddSeries("数量", data)
+	return heatMap
+}
+
+// 类似GitHub贡献图的活动日历，每年一个，最近的年份排在最前面
+func newActivityCalendars(style chartStyle, times []time.Time) []components.Charter {
+	daily := make(map[int]map[string]int)
+	for _, t := range times {
+		if daily[t.Year()] == nil {
+			daily[t.Year()] = make(map[string]int)
+		}
+		daily[t.Year()][t.Format("2006-01-02")]++
+	}
+	years := make([]int, 0, len(daily))
+	for year := range daily {
+		years = append(years, year)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(years)))
+	if len(years) > activityCalendarYears {
+		years = years[:activityCalendarYears]
+	}
+
+	items := make([]components.Charter, 0, len(years))
+	for _, year := range years {
+		days := make([]string, 0, len(daily[year]))
+		for day := range daily[year] {
+			days = append(days, day)
+		}
+		sort.Strings(days)
+
+		data := make([]opts.HeatMapData, len(days))
+		maxCount, total := 0, 0
+		for i, day := range days {
+			count := daily[year][day]
+			data[i] = opts.HeatMapData{Value: [2]interface{}{day, count}}
+			total += count
+			if count > maxCount {
+				maxCount = count
+			}
+		}
+
+		heatMap := charts.NewHeatMap()
+		heatMap.SetGlobalOptions(
+			style.initOpts("1000px", "300px"),
+			charts.WithTitleOpts(opts.Title{
+				Title:    fmt.Sprintf("%d年活动日历", year),
+				Subtitle: fmt.Sprintf("共 %d 条，活跃 %d 天", total, len(days)),
+			}),
+			charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+			charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+			charts.WithVisualMapOpts(opts.VisualMap{
+				Calculable: opts.Bool(true),
+				Min:        0,
+				Max:        float32(maxCount),
+				Orient:     "horizontal",
+				Left:       "center",
+				Bottom:     "0px",
+				InRange:    &opts.VisualMapInRange{Color: []string{"#ebedf0", "#9be9a8", "#40c463", "#216e39"}},
+			}),
+		)
+		heatMap.AddCalendar(&opts.Calendar{
+			Top:       "80px",
+			Left:      "50px",
+			Right:     "30px",
+			CellSize:  "auto",
+			Range:     []string{fmt.Sprintf("%d", year)},
+			DayLabel:  &opts.CalendarLabel{FirstDay: 1},
+			YearLabel: &opts.CalendarLabel{Show: opts.Bool(false)},
+		})
+		heatMap.AddSeries("数量", data, charts.WithCoordinateSystem("calendar"))
+		items = append(items, heatMap)
+	}
+	return items
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// 默认的图表统计周期
+const defaultChartGranularity = "month"
+
+// 支持的图表统计周期及其显示名称
+var chartGranularityNames = map[string]string{
+	"day":     "日",
+	"week":    "周",
+	"month":   "月",
+	"quarter": "季度",
+}
+
+// 检查图表统计周期，为空时使用默认周期
+func parseChartGranularity(granularity string) (string, error) {
+	if granularity == "" {
+		return defaultChartGranularity, nil
+	}
+	if _, ok := chartGranularityNames[granularity]; !ok {
+		return "", fmt.Errorf("不支持的图表统计周期: %s，可选值: day, week, month, quarter", granularity)
+	}
+	return granularity, nil
+}
+
+// 时间所在周期的起始时间，周从周一开始
+func periodStart(t time.Time, granularity string) time.Time {
+	t = t.UTC()
+	switch granularity {
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+	case "quarter":
+		month := (t.Month()-1)/3*3 + 1
+		return time.Date(t.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// 下一个周期的起始时间
+func nextPeriod(start time.Time, granularity string) time.Time {
+	switch granularity {
+	case "day":
+		return start.AddDate(0, 0, 1)
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "quarter":
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// 周期在图表坐标轴上的名称
+func periodLabel(start time.Time, granularity string) string {
+	switch granularity {
+	case "day", "week":
+		return start.Format("2006-01-02")
+	case "quarter":
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// 生成积压趋势图：每个周期新建和关闭的issues数量，以及累计新建、累计关闭和未关闭数量
+func generateBacklogChart(c *chartContext) error {
+	issues, granularity, style := c.issues, c.granularity, c.style
+	if len(issues) == 0 {
+		return fmt.Errorf("%w: 没有issues", errChartSkipped)
+	}
+
+	// 统计每个周期新建和关闭的数量
+	opened := make(map[time.Time]int)
+	closed := make(map[time.Time]int)
+	first := periodStart(issues[0].GetCreatedAt().Time, granularity)
+	last := first
+	for _, issue := range issues {
+		start := periodStart(issue.GetCreatedAt().Time, granularity)
+		opened[start]++
+		if start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+		if issue.GetState() == "closed" && issue.ClosedAt != nil {
+			start = periodStart(issue.GetClosedAt().Time, granularity)
+			closed[start]++
+			if start.After(last) {
+				last = start
+			}
+		}
+	}
+
+	// 按周期累计，没有变化的周期也保留
+	var (
+		periods                        []string
+		openedBars, closedBars         []opts.BarData
+		openedTotal, closedTotal, open []opts.LineData
+		totalOpened, totalClosed       int
+	)
+	for start := first; !start.After(last); start = nextPeriod(start, granularity) {
+		totalOpened += opened[start]
+		totalClosed += closed[start]
+		periods = append(periods, periodLabel(start, granularity))
+		openedBars = append(openedBars, opts.BarData{Value: opened[start]})
+		closedBars = append(closedBars, opts.BarData{Value: closed[start]})
+		openedTotal = append(openedTotal, opts.LineData{Value: totalOpened})
+		closedTotal = append(closedTotal, opts.LineData{Value: totalClosed})
+		open = append(open, opts.LineData{Value: totalOpened - totalClosed})
+	}
+
+	unit := chartGranularityNames[granularity]
+	zoom := []charts.GlobalOpts{
+		charts.WithDataZoomOpts(opts.DataZoom{Type: "inside", Start: 0, End: 100}),
+		charts.WithDataZoomOpts(opts.DataZoom{Type: "slider", Start: 0, End: 100}),
+	}
+
+	// 累计新建、累计关闭和未关闭数量
+	burnUp := charts.NewLine()
+	burnUp.SetGlobalOptions(append([]charts.GlobalOpts{
+		style.initOpts("1000px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "Issues积压趋势",
+			Subtitle: fmt.Sprintf("按%s统计，累计新建 %d 个，累计关闭 %d 个，未关闭 %d 个", unit, totalOpened, totalClosed, totalOpened-totalClosed),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true)}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      unit,
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "数量",
+		}),
+	}, zoom...)...)
+	burnUp.SetXAxis(periods).
+		AddSeries("累计新建", openedTotal).
+		AddSeries("累计关闭", closedTotal).
+		AddSeries("未关闭", open, charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: opts.Float(0.2)}))
+
+	// 每个周期新建和关闭的数量
+	flow := charts.NewBar()
+	flow.SetGlobalOptions(append([]charts.GlobalOpts{
+		style.initOpts("1000px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "每" + unit + "新建与关闭",
+			Subtitle: "关闭数持续低于新建数时积压会增加",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true)}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      unit,
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "数量",
+		}),
+	}, zoom...)...)
+	flow.SetXAxis(periods).
+		AddSeries("新建", openedBars).
+		AddSeries("关闭", closedBars)
+
+	return saveChartPage(filepath.Join(c.dir, "backlog_chart.html"), "Issues积压趋势", burnUp, flow)
+}
@@ -0,0 +1,467 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// 仪表盘文件名，位于图表目录下
+const dashboardFile = "dashboard.html"
+
+// 仪表盘中嵌入的issue数据，由页面中的脚本筛选和统计
+type dashboardIssue struct {
+	Number    int      `json:"number"`
+	Title     string   `json:"title"`
+	State     string   `json:"state"`
+	Author    string   `json:"author"`
+	Labels    []string `json:"labels"`
+	Assignees []string `json:"assignees"`
+	Milestone string   `json:"milestone,omitempty"`
+	Comments  int      `json:"comments"`
+	CreatedAt string   `json:"createdAt"`
+	ClosedAt  string   `json:"closedAt,omitempty"`
+	URL       string   `json:"url"`
+
+	// 首次响应时间（小时），未下载评论或没有响应时为空
+	FirstResponseHours *float64 `json:"firstResponseHours,omitempty"`
+}
+
+// 页面中时长分布的分组，MaxHours为0表示不设上限
+type dashboardBucket struct {
+	Label    string  `json:"label"`
+	MaxHours float64 `json:"maxHours"`
+}
+
+type dashboardPage struct {
+	Title       string
+	GeneratedAt string
+	Now         string
+	AssetsHost  string
+	Theme       string
+	Issues      []dashboardIssue
+
+	// 是否下载了评论，未下载时首次响应时间图显示提示
+	CommentsLoaded bool
+
+	ResponseBuckets   []dashboardBucket
+	ResolutionBuckets []dashboardBucket
+	OpenAgeBuckets    []dashboardBucket
+}
+
+// 将时长分组转换为页面中使用的分组，与单独的图表页面保持一致
+func newDashboardBuckets(buckets []durationBucket) []dashboardBucket {
+	result := make([]dashboardBucket, len(buckets))
+	for i, b := range buckets {
+		result[i] = dashboardBucket{Label: b.Label}
+		if b.Max != math.MaxInt64 {
+			result[i].MaxHours = b.Max.Hours()
+		}
+	}
+	return result
+}
+
+// 生成单页交互式仪表盘：所有issue数据嵌入页面，在浏览器中按时间、标签、状态和作者筛选后重新绘制图表
+func generateDashboard(c *chartContext) error {
+	// 导出文件脱敏时，仪表盘中的标题同样脱敏
+	r, err := newExportRedactor(c.cfg)
+	if err != nil {
+		return err
+	}
+
+	data := make([]dashboardIssue, 0, len(c.issues))
+	for _, issue := range c.issues {
+		item := dashboardIssue{
+			Number:    issue.GetNumber(),
+			Title:     issue.GetTitle(),
+			State:     issue.GetState(),
+			Author:    issue.GetUser().GetLogin(),
+			Labels:    []string{},
+			Assignees: []string{},
+			Milestone: issue.GetMilestone().GetTitle(),
+			Comments:  issue.GetComments(),
+			CreatedAt: issue.GetCreatedAt().Format(time.RFC3339),
+			URL:       issue.GetHTMLURL(),
+		}
+		item.Title = r.redact(item.Title, fmt.Sprintf("issue #%d", item.Number))
+		if issue.GetState() == "closed" && issue.ClosedAt != nil {
+			item.ClosedAt = issue.GetClosedAt().Format(time.RFC3339)
+		}
+		for _, label := range issue.Labels {
+			item.Labels = append(item.Labels, label.GetName())
+		}
+		for _, assignee := range issue.Assignees {
+			item.Assignees = append(item.Assignees, assignee.GetLogin())
+		}
+		if c.comments != nil {
+			if d, ok := firstResponseTime(issue, c.comments[issue.GetNumber()]); ok {
+				hours := d.Hours()
+				item.FirstResponseHours = &hours
+			}
+		}
+		data = append(data, item)
+	}
+
+	tmpl, err := template.New("dashboard").Parse(dashboardTemplate)
+	if err != nil {
+		return fmt.Errorf("解析仪表盘模板失败: %w", err)
+	}
+	f, err := os.Create(filepath.Join(c.dir, dashboardFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	now := time.Now()
+	return tmpl.Execute(f, dashboardPage{
+		Title:             "GitHub Issues 仪表盘",
+		GeneratedAt:       now.Format("2006-01-02 15:04"),
+		Now:               now.Format(time.RFC3339),
+		AssetsHost:        "https://go-echarts.github.io/go-echarts-assets/assets/",
+		Theme:             c.style.Theme,
+		Issues:            data,
+		CommentsLoaded:    c.comments != nil,
+		ResponseBuckets:   newDashboardBuckets(responseTimeBuckets),
+		ResolutionBuckets: newDashboardBuckets(resolutionTimeBuckets),
+		OpenAgeBuckets:    newDashboardBuckets(openAgeBuckets),
+	})
+}
+
+// 仪表盘页面模板，数据以JSON嵌入脚本，筛选和统计都在浏览器中完成
+const dashboardTemplate = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<script src="{{.AssetsHost}}echarts.min.js"></script>
+{{if and (ne .Theme "white") (ne .Theme "dark")}}<script src="{{.AssetsHost}}themes/{{.Theme}}.js"></script>{{end}}
+<style>
+  body { margin: 0; font-family: -apple-system, "Segoe UI", "PingFang SC", "Microsoft YaHei", sans-serif; background: #f5f6f8; color: #333; }
+  header { padding: 16px 24px; background: #fff; border-bottom: 1px solid #e5e5e5; }
+  header h1 { margin: 0; font-size: 22px; }
+  header small { color: #888; }
+  .filters { display: flex; flex-wrap: wrap; gap: 12px; align-items: flex-end; padding: 12px 24px; background: #fff; border-bottom: 1px solid #e5e5e5; position: sticky; top: 0; z-index: 10; }
+  .filters label { display: flex; flex-direction: column; font-size: 12px; color: #666; gap: 4px; }
+  .filters input, .filters select, .filters button { font-size: 14px; padding: 4px 8px; }
+  .stats { display: flex; gap: 12px; padding: 16px 24px 0; flex-wrap: wrap; }
+  .stat { background: #fff; border-radius: 6px; padding: 12px 20px; min-width: 120px; }
+  .stat b { display: block; font-size: 24px; }
+  .stat span { font-size: 12px; color: #888; }
+  .grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(560px, 1fr)); gap: 16px; padding: 16px 24px; }
+  .chart { background: #fff; border-radius: 6px; height: 420px; }
+  .table { margin: 0 24px 24px; background: #fff; border-radius: 6px; padding: 12px; }
+  table { width: 100%; border-collapse: collapse; font-size: 13px; }
+  th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #eee; }
+  a { color: #2f6fb5; text-decoration: none; }
+</style>
+</head>
+<body>
+<header>
+  <h1>{{.Title}}</h1>
+  <small>生成于 {{.GeneratedAt}}，共 {{len .Issues}} 个issues，筛选在浏览器中完成，无需重新运行工具。<a href="index.html">查看单独的图表</a></small>
+</header>
+<div class="filters">
+  <label>创建时间从<input type="date" id="from"></label>
+  <label>到<input type="date" id="to"></label>
+  <label>状态<select id="state"><option value="">全部</option><option value="open">open</option><option value="closed">closed</option></select></label>
+  <label>标签<select id="label"><option value="">全部</option></select></label>
+  <label>作者<select id="author"><option value="">全部</option></select></label>
+  <button id="reset">重置</button>
+</div>
+<div class="stats">
+  <div class="stat"><b id="stat-total">0</b><span>Issues</span></div>
+  <div class="stat"><b id="stat-open">0</b><span>未关闭</span></div>
+  <div class="stat"><b id="stat-closed">0</b><span>已关闭</span></div>
+  <div class="stat"><b id="stat-median">-</b><span>关闭耗时中位数（天）</span></div>
+  <div class="stat"><b id="stat-authors">0</b><span>提交者</span></div>
+</div>
+<div class="grid">
+  <div class="chart" id="chart-status"></div>
+  <div class="chart" id="chart-labels"></div>
+  <div class="chart" id="chart-timeline"></div>
+  <div class="chart" id="chart-backlog"></div>
+  <div class="chart" id="chart-resolution"></div>
+  <div class="chart" id="chart-authors"></div>
+  <div class="chart" id="chart-cooccurrence"></div>
+  <div class="chart" id="chart-milestones"></div>
+  <div class="chart" id="chart-response"></div>
+  <div class="chart" id="chart-openage"></div>
+</div>
+<div class="table">
+  <table>
+    <thead><tr><th>编号</th><th>标题</th><th>状态</th><th>作者</th><th>标签</th><th>创建时间</th></tr></thead>
+    <tbody id="issues"></tbody>
+  </table>
+  <small id="table-note"></small>
+</div>
+<script>
+var ISSUES = {{.Issues}};
+var THEME = {{.Theme}};
+var NOW = new Date({{.Now}});
+var COMMENTS_LOADED = {{.CommentsLoaded}};
+var RESPONSE_BUCKETS = {{.ResponseBuckets}};
+var RESOLUTION_BUCKETS = {{.ResolutionBuckets}};
+var OPEN_AGE_BUCKETS = {{.OpenAgeBuckets}};
+var HOUR = 3600 * 1000;
+var DAY = 24 * HOUR;
+var TABLE_LIMIT = 200;
+var COOCCURRENCE_LABELS = 10;
+var MILESTONE_LIMIT = 15;
+
+ISSUES.forEach(function (i) {
+  i.created = new Date(i.createdAt);
+  i.closed = i.closedAt ? new Date(i.closedAt) : null;
+});
+
+function $(id) { return document.getElementById(id); }
+
+function countBy(items, key) {
+  var counts = {};
+  items.forEach(function (i) {
+    [].concat(key(i)).forEach(function (k) { counts[k] = (counts[k] || 0) + 1; });
+  });
+  return Object.keys(counts).map(function (k) { return [k, counts[k]]; })
+    .sort(function (a, b) { return b[1] - a[1] || (a[0] < b[0] ? -1 : 1); });
+}
+
+function fillSelect(id, entries) {
+  var select = $(id);
+  entries.forEach(function (e) {
+    var option = document.createElement("option");
+    option.value = e[0];
+    option.textContent = e[0] + " (" + e[1] + ")";
+    select.appendChild(option);
+  });
+}
+
+function month(d) { return d.toISOString().slice(0, 7); }
+
+function monthRange(items) {
+  if (!items.length) { return []; }
+  var min = items[0].created, max = items[0].created;
+  items.forEach(function (i) {
+    if (i.created < min) { min = i.created; }
+    if (i.created > max) { max = i.created; }
+    if (i.closed && i.closed > max) { max = i.closed; }
+  });
+  var months = [], d = new Date(Date.UTC(min.getUTCFullYear(), min.getUTCMonth(), 1));
+  while (d <= max) {
+    months.push(month(d));
+    d.setUTCMonth(d.getUTCMonth() + 1);
+  }
+  return months;
+}
+
+// 按分组统计时长（小时）的数量，返回 [分组名, 数量]
+function bucketCounts(buckets, hours) {
+  var counts = buckets.map(function () { return 0; });
+  hours.forEach(function (h) {
+    for (var k = 0; k < buckets.length; k++) {
+      if (!buckets[k].maxHours || h <= buckets[k].maxHours) { counts[k]++; break; }
+    }
+  });
+  return buckets.map(function (b, k) { return [b.label, counts[k]]; });
+}
+
+function median(values) {
+  if (!values.length) { return null; }
+  var sorted = values.slice().sort(function (a, b) { return a - b; });
+  var mid = Math.floor(sorted.length / 2);
+  return sorted.length % 2 ? sorted[mid] : (sorted[mid - 1] + sorted[mid]) / 2;
+}
+
+var charts = {};
+["status", "labels", "timeline", "backlog", "resolution", "authors",
+  "cooccurrence", "milestones", "response", "openage"].forEach(function (name) {
+  charts[name] = echarts.init($("chart-" + name), THEME);
+});
+window.addEventListener("resize", function () {
+  Object.keys(charts).forEach(function (name) { charts[name].resize(); });
+});
+
+function filtered() {
+  var from = $("from").value ? new Date($("from").value + "T00:00:00") : null;
+  var to = $("to").value ? new Date(new Date($("to").value + "T00:00:00").getTime() + DAY) : null;
+  var state = $("state").value, label = $("label").value, author = $("author").value;
+  return ISSUES.filter(function (i) {
+    return (!from || i.created >= from) && (!to || i.created < to) &&
+      (!state || i.state === state) &&
+      (!label || i.labels.indexOf(label) >= 0) &&
+      (!author || i.author === author);
+  });
+}
+
+function bar(title, entries, horizontal) {
+  var names = entries.map(function (e) { return e[0]; });
+  var values = entries.map(function (e) { return e[1]; });
+  var category = { type: "category", data: horizontal ? names.slice().reverse() : names, axisLabel: { rotate: horizontal ? 0 : 45 } };
+  var value = { type: "value" };
+  return {
+    title: { text: title },
+    tooltip: {},
+    grid: { left: horizontal ? 140 : 60, bottom: 90 },
+    xAxis: horizontal ? value : category,
+    yAxis: horizontal ? category : value,
+    series: [{ type: "bar", data: horizontal ? values.slice().reverse() : values, label: { show: true, position: horizontal ? "right" : "top" } }]
+  };
+}
+
+function render() {
+  var items = filtered();
+  var open = items.filter(function (i) { return i.state === "open"; });
+  var durations = items.filter(function (i) { return i.closed; })
+    .map(function (i) { return (i.closed - i.created) / DAY; });
+  var med = median(durations);
+
+  $("stat-total").textContent = items.length;
+  $("stat-open").textContent = open.length;
+  $("stat-closed").textContent = items.length - open.length;
+  $("stat-median").textContent = med === null ? "-" : med.toFixed(1);
+  $("stat-authors").textContent = countBy(items, function (i) { return i.author; }).length;
+
+  charts.status.setOption({
+    title: { text: "状态分布" },
+    tooltip: { trigger: "item" },
+    legend: { bottom: 0 },
+    series: [{ type: "pie", radius: ["40%", "70%"], label: { formatter: "{b}: {c} ({d}%)" },
+      data: countBy(items, function (i) { return i.state; }).map(function (e) { return { name: e[0], value: e[1] }; }) }]
+  }, true);
+
+  charts.labels.setOption(bar("标签分布（前15个）",
+    countBy(items, function (i) { return i.labels.length ? i.labels : ["无标签"]; }).slice(0, 15)), true);
+
+  var months = monthRange(items);
+  var created = {}, closed = {};
+  items.forEach(function (i) {
+    created[month(i.created)] = (created[month(i.created)] || 0) + 1;
+    if (i.closed) { closed[month(i.closed)] = (closed[month(i.closed)] || 0) + 1; }
+  });
+  charts.timeline.setOption({
+    title: { text: "每月新建与关闭" },
+    tooltip: { trigger: "axis" },
+    legend: { top: 30 },
+    grid: { top: 70, bottom: 90 },
+    dataZoom: [{ type: "inside" }, { type: "slider" }],
+    xAxis: { type: "category", data: months, axisLabel: { rotate: 45 } },
+    yAxis: { type: "value" },
+    series: [
+      { name: "新建", type: "bar", data: months.map(function (m) { return created[m] || 0; }) },
+      { name: "关闭", type: "bar", data: months.map(function (m) { return closed[m] || 0; }) }
+    ]
+  }, true);
+
+  var totalCreated = 0, totalClosed = 0;
+  var backlog = months.map(function (m) {
+    totalCreated += created[m] || 0;
+    totalClosed += closed[m] || 0;
+    return [totalCreated, totalClosed, totalCreated - totalClosed];
+  });
+  charts.backlog.setOption({
+    title: { text: "积压趋势" },
+    tooltip: { trigger: "axis" },
+    legend: { top: 30 },
+    grid: { top: 70, bottom: 90 },
+    dataZoom: [{ type: "inside" }, { type: "slider" }],
+    xAxis: { type: "category", data: months, axisLabel: { rotate: 45 } },
+    yAxis: { type: "value" },
+    series: [
+      { name: "累计新建", type: "line", data: backlog.map(function (b) { return b[0]; }) },
+      { name: "累计关闭", type: "line", data: backlog.map(function (b) { return b[1]; }) },
+      { name: "未关闭", type: "line", areaStyle: { opacity: 0.2 }, data: backlog.map(function (b) { return b[2]; }) }
+    ]
+  }, true);
+
+  charts.resolution.setOption(bar("关闭耗时分布",
+    bucketCounts(RESOLUTION_BUCKETS, durations.map(function (d) { return d * 24; }))), true);
+
+  charts.authors.setOption(bar("提交Issues最多的用户",
+    countBy(items, function (i) { return i.author; }).slice(0, 10), true), true);
+
+  // 标签共现：前几个标签同时出现在同一个issue上的次数，对角线为该标签的issues数量
+  var topLabels = countBy(items, function (i) { return i.labels; }).slice(0, COOCCURRENCE_LABELS)
+    .map(function (e) { return e[0]; });
+  var cooccurrence = [], maxPair = 1;
+  topLabels.forEach(function (a, x) {
+    topLabels.forEach(function (b, y) {
+      var n = items.filter(function (i) { return i.labels.indexOf(a) >= 0 && i.labels.indexOf(b) >= 0; }).length;
+      cooccurrence.push([x, y, n]);
+      if (x !== y && n > maxPair) { maxPair = n; }
+    });
+  });
+  charts.cooccurrence.setOption({
+    title: { text: "标签共现（前" + topLabels.length + "个标签）" },
+    tooltip: {},
+    grid: { left: 120, bottom: 110 },
+    xAxis: { type: "category", data: topLabels, axisLabel: { rotate: 45, interval: 0 }, splitArea: { show: true } },
+    yAxis: { type: "category", data: topLabels, axisLabel: { interval: 0 }, splitArea: { show: true } },
+    visualMap: { min: 0, max: maxPair, calculable: true, orient: "horizontal", left: "center", bottom: 0,
+      inRange: { color: ["#f7fbff", "#6baed6", "#08306b"] } },
+    series: [{ type: "heatmap", data: cooccurrence, label: { show: true } }]
+  }, true);
+
+  // 里程碑：按issues所属里程碑统计未关闭和已关闭的数量
+  var milestones = countBy(items.filter(function (i) { return i.milestone; }), function (i) { return i.milestone; })
+    .slice(0, MILESTONE_LIMIT).map(function (e) { return e[0]; });
+  function milestoneCount(name, state) {
+    return items.filter(function (i) { return i.milestone === name && i.state === state; }).length;
+  }
+  charts.milestones.setOption({
+    title: { text: "里程碑进度", subtext: milestones.length ? "按issues所属里程碑统计" : "没有关联里程碑的issues" },
+    tooltip: { trigger: "axis" },
+    legend: { top: 30 },
+    grid: { left: 140, top: 70 },
+    xAxis: { type: "value" },
+    yAxis: { type: "category", data: milestones.slice().reverse() },
+    series: [
+      { name: "已关闭", type: "bar", stack: "total", data: milestones.slice().reverse().map(function (m) { return milestoneCount(m, "closed"); }) },
+      { name: "未关闭", type: "bar", stack: "total", data: milestones.slice().reverse().map(function (m) { return milestoneCount(m, "open"); }) }
+    ]
+  }, true);
+
+  // 首次响应时间：第一条非作者、非机器人评论，需要导出时下载评论
+  if (COMMENTS_LOADED) {
+    var responded = items.filter(function (i) { return i.firstResponseHours !== undefined; });
+    var response = bucketCounts(RESPONSE_BUCKETS, responded.map(function (i) { return i.firstResponseHours; }));
+    response.push(["未响应", items.length - responded.length]);
+    charts.response.setOption(bar("首次响应时间分布", response), true);
+  } else {
+    charts.response.setOption({ title: { text: "首次响应时间分布", subtext: "未下载评论，使用 -comment 导出后显示" } }, true);
+  }
+
+  // 未关闭issues存在时长，按仪表盘生成时间计算
+  charts.openage.setOption(bar("未关闭Issues存在时长",
+    bucketCounts(OPEN_AGE_BUCKETS, open.map(function (i) { return (NOW - i.created) / HOUR; }))), true);
+
+  var rows = items.slice().sort(function (a, b) { return b.number - a.number; }).slice(0, TABLE_LIMIT);
+  var tbody = $("issues");
+  tbody.innerHTML = "";
+  rows.forEach(function (i) {
+    var tr = document.createElement("tr");
+    var link = document.createElement("a");
+    link.href = i.url;
+    link.target = "_blank";
+    link.textContent = "#" + i.number;
+    [link, i.title, i.state, i.author, i.labels.join(", "), i.createdAt.slice(0, 10)].forEach(function (value) {
+      var td = document.createElement("td");
+      if (typeof value === "string") { td.textContent = value; } else { td.appendChild(value); }
+      tr.appendChild(td);
+    });
+    tbody.appendChild(tr);
+  });
+  $("table-note").textContent = items.length > TABLE_LIMIT ? "只显示编号最大的 " + TABLE_LIMIT + " 个issues" : "";
+}
+
+fillSelect("label", countBy(ISSUES, function (i) { return i.labels; }));
+fillSelect("author", countBy(ISSUES, function (i) { return i.author; }));
+["from", "to", "state", "label", "author"].forEach(function (id) { $(id).addEventListener("change", render); });
+$("reset").addEventListener("click", function () {
+  ["from", "to", "state", "label", "author"].forEach(function (id) { $(id).value = ""; });
+  render();
+});
+render();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestGenerateDashboardIncludesAllCharts(t *testing.T) {
+	created := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	issue := &github.Issue{
+		Number:    github.Int(1),
+		Title:     github.String("crash"),
+		State:     github.String("open"),
+		User:      &github.User{Login: github.String("alice")},
+		Labels:    []*github.Label{{Name: github.String("bug")}, {Name: github.String("ui")}},
+		Milestone: &github.Milestone{Title: github.String("v1.0")},
+		CreatedAt: &github.Timestamp{Time: created},
+	}
+	comments := map[int][]*github.IssueComment{
+		1: {{
+			User:      &github.User{Login: github.String("bob")},
+			CreatedAt: &github.Timestamp{Time: created.Add(90 * time.Minute)},
+		}},
+	}
+	dir := t.TempDir()
+	c := &chartContext{
+		chartData: &chartData{issues: []*github.Issue{issue}, comments: comments},
+		cfg:       &Config{},
+		dir:       dir,
+	}
+	if err := generateDashboard(c); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, dashboardFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	page := string(data)
+	for _, id := range []string{"cooccurrence", "milestones", "response", "openage"} {
+		if !strings.Contains(page, `id="chart-`+id+`"`) || !strings.Contains(page, `"`+id+`"`) {
+			t.Errorf("仪表盘缺少图表 %s", id)
+		}
+	}
+	if !strings.Contains(page, `"firstResponseHours":1.5`) {
+		t.Errorf("仪表盘数据缺少首次响应时间")
+	}
+	if !strings.Contains(page, "var COMMENTS_LOADED =  true ;") {
+		t.Errorf("下载了评论时应显示首次响应时间")
+	}
+}
+
+func TestNewDashboardBuckets(t *testing.T) {
+	got := newDashboardBuckets(openAgeBuckets)
+	if len(got) != len(openAgeBuckets) {
+		t.Fatalf("分组数量 = %d，期望 %d", len(got), len(openAgeBuckets))
+	}
+	if last := got[len(got)-1]; last.MaxHours != 0 {
+		t.Errorf("最后一个分组不应设上限: %+v", last)
+	}
+	if _, err := json.Marshal(got); err != nil {
+		t.Fatal(err)
+	}
+}
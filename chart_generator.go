@@ -1,10 +1,12 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"html"
 	"os"
 	"path/filepath"
-	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
@@ -14,54 +16,230 @@ import (
 	"github.com/google/go-github/v57/github"
 )
 
-// 生成所有图表
-func generateCharts(issues []*github.Issue, outputDirPath string) error {
+// 默认的图表主题
+const defaultChartTheme = types.ThemeWesteros
+
+// 支持的图表主题
+var chartThemes = []string{
+	"white", "dark",
+	types.ThemeChalk, types.ThemeEssos, types.ThemeInfographic, types.ThemeMacarons,
+	types.ThemePurplePassion, types.ThemeRoma, types.ThemeRomantic, types.ThemeShine,
+	types.ThemeVintage, types.ThemeWalden, types.ThemeWesteros, types.ThemeWonderland,
+}
+
+// errChartSkipped 表示缺少生成图表所需的数据，跳过该图表
+var errChartSkipped = errors.New("跳过图表")
+
+// 图表索引页中的一项
+type chartIndexEntry struct {
+	File  string
+	Title string
+}
+
+// 生成图表使用的数据
+type chartData struct {
+	issues []*github.Issue
+
+	// 每个issue的评论，为nil表示没有下载评论
+	comments map[int][]*github.IssueComment
+
+	// 仓库的所有里程碑
+	milestones []*github.Milestone
+}
+
+// 图表的主题和尺寸，Width和Height为空时使用每个图表的默认尺寸
+type chartStyle struct {
+	Theme  string
+	Width  string
+	Height string
+}
+
+// 图表的初始化选项，width和height为图表的默认尺寸
+func (s chartStyle) initOpts(width, height string) charts.GlobalOpts {
+	if s.Width != "" {
+		width = s.Width
+	}
+	if s.Height != "" {
+		height = s.Height
+	}
+	return charts.WithInitializationOpts(opts.Initialization{
+		Theme:  s.Theme,
+		Width:  width,
+		Height: height,
+	})
+}
+
+// 生成图表时使用的数据和配置
+type chartContext struct {
+	*chartData
+	cfg         *Config
+	dir         string
+	style       chartStyle
+	labels      labelSelection
+	granularity string
+	topPeople   int
+	location    *time.Location
+}
+
+// 图表索引页文件名，位于图表目录下
+const chartsIndexFile = "index.html"
+
+// 图表生成器，生成的文件会加入图表索引页
+type chartGenerator struct {
+	// 图表名，用于配置 chartList
+	Name string
+
+	// 生成的文件相对于图表目录的路径，用于索引页中的链接
+	File string
+
+	// 在索引页中显示的标题
+	Title string
+
+	// 生成图表，缺少所需数据时返回包装了errChartSkipped的错误
+	Generate func(c *chartContext) error
+}
+
+// 内置的图表生成器，按顺序生成
+var chartGenerators = []chartGenerator{
+	{"dashboard", dashboardFile, "交互式仪表盘（可按时间、标签、状态和作者筛选）", generateDashboard},
+	{"status", "status_chart.html", "状态分布图", generateStatusChart},
+	{"labels", "labels_chart.html", "标签分布图", generateLabelsChart},
+	{"label_analysis", "label_analysis_chart.html", "标签分析", generateLabelAnalysisChart},
+	{"timeline", "timeline_chart.html", "时间趋势图", generateTimelineChart},
+	{"backlog", "backlog_chart.html", "积压趋势图", generateBacklogChart},
+	{"people", "people_chart.html", "人员分析", generatePeopleChart},
+	{"activity", "activity_chart.html", "活动时间分布", generateActivityChart},
+	{"milestones", "milestone_chart.html", "里程碑进度", generateMilestoneChart},
+	{"response_time", "response_time_chart.html", "首次响应时间", generateResponseTimeChart},
+	{"resolution_time", "resolution_time_chart.html", "关闭耗时", generateResolutionTimeChart},
+	{"open_age", "open_age_chart.html", "未关闭Issues存在时长", generateOpenAgeChart},
+	{"health", "../" + healthReportFile, "仓库健康报告", generateHealthReport},
+}
+
+// registerChart 注册额外的图表生成器，通常在init函数中调用，注册的图表排在内置图表之后
+func registerChart(g chartGenerator) {
+	chartGenerators = append(chartGenerators, g)
+}
+
+// 根据配置创建图表上下文
+func newChartContext(cfg *Config, data *chartData, chartsDir string) (*chartContext, error) {
+	granularity, err := parseChartGranularity(cfg.ChartGranularity)
+	if err != nil {
+		return nil, err
+	}
+	theme := cfg.ChartTheme
+	if theme == "" {
+		theme = defaultChartTheme
+	}
+	if !containsString(chartThemes, theme) {
+		return nil, fmt.Errorf("不支持的图表主题: %s，可选值: %s", theme, strings.Join(chartThemes, ", "))
+	}
+	location := time.Local
+	if cfg.ChartTimezone != "" {
+		if location, err = time.LoadLocation(cfg.ChartTimezone); err != nil {
+			return nil, fmt.Errorf("无效的图表时区 %s: %w", cfg.ChartTimezone, err)
+		}
+	}
+	topPeople := cfg.ChartTopPeople
+	if topPeople <= 0 {
+		topPeople = defaultChartTopPeople
+	}
+	return &chartContext{
+		chartData:   data,
+		cfg:         cfg,
+		dir:         chartsDir,
+		style:       chartStyle{Theme: theme, Width: cfg.ChartWidth, Height: cfg.ChartHeight},
+		labels:      newLabelSelection(cfg),
+		granularity: granularity,
+		topPeople:   topPeople,
+		location:    location,
+	}, nil
+}
+
+// 需要生成的图表，chartList为空时生成所有图表
+func selectChartGenerators(names []string) ([]chartGenerator, error) {
+	if len(names) == 0 {
+		return chartGenerators, nil
+	}
+	available := make([]string, len(chartGenerators))
+	for i, g := range chartGenerators {
+		available[i] = g.Name
+	}
+	for _, name := range names {
+		if !containsString(available, name) {
+			return nil, fmt.Errorf("未知的图表: %s，可选值: %s", name, strings.Join(available, ", "))
+		}
+	}
+
+	var selected []chartGenerator
+	for _, g := range chartGenerators {
+		if containsString(names, g.Name) {
+			selected = append(selected, g)
+		}
+	}
+	return selected, nil
+}
+
+// 生成配置中选择的图表，缺少评论或里程碑数据时跳过依赖这些数据的图表
+// 返回查看图表的入口页面：生成了仪表盘时为仪表盘，否则为图表索引页
+func generateCharts(cfg *Config, data *chartData, outputDirPath string) (string, error) {
 	// 创建图表目录
 	chartsDir := filepath.Join(outputDirPath, "charts")
 	if err := os.MkdirAll(chartsDir, 0755); err != nil {
-		return fmt.Errorf("创建图表目录失败: %w", err)
+		return "", fmt.Errorf("创建图表目录失败: %w", err)
 	}
 
-	// 生成状态分布图
-	if err := generateStatusChart(issues, chartsDir); err != nil {
-		return fmt.Errorf("生成状态分布图失败: %w", err)
+	c, err := newChartContext(cfg, data, chartsDir)
+	if err != nil {
+		return "", err
+	}
+	generators, err := selectChartGenerators(cfg.ChartList)
+	if err != nil {
+		return "", err
 	}
 
-	// 生成标签分布图
-	if err := generateLabelsChart(issues, chartsDir); err != nil {
-		return fmt.Errorf("生成标签分布图失败: %w", err)
+	var index []chartIndexEntry
+	entry := filepath.Join(chartsDir, chartsIndexFile)
+	for _, g := range generators {
+		if err := g.Generate(c); errors.Is(err, errChartSkipped) {
+			fmt.Printf("提示: %s: %v\n", g.Title, err)
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("生成%s失败: %w", g.Title, err)
+		}
+		index = append(index, chartIndexEntry{g.File, g.Title})
+		if g.File == dashboardFile {
+			entry = filepath.Join(chartsDir, dashboardFile)
+		}
 	}
 
-	// 生成时间趋势图
-	if err := generateTimelineChart(issues, chartsDir); err != nil {
-		return fmt.Errorf("生成时间趋势图失败: %w", err)
+	// 将状态、标签和时间趋势图另存为SVG图片
+	if cfg.ChartImages {
+		if err := generateChartImages(data.issues, c.labels, chartsDir); err != nil {
+			return "", fmt.Errorf("生成图表图片失败: %w", err)
+		}
+		index = append(index, chartImages...)
 	}
 
 	// 生成图表索引页
-	if err := generateChartsIndex(chartsDir); err != nil {
-		return fmt.Errorf("生成图表索引页失败: %w", err)
+	if err := generateChartsIndex(chartsDir, index); err != nil {
+		return "", fmt.Errorf("生成图表索引页失败: %w", err)
 	}
 
-	return nil
+	return entry, nil
 }
 
 // 生成状态分布图
-func generateStatusChart(issues []*github.Issue, chartsDir string) error {
+func generateStatusChart(c *chartContext) error {
+	issues, style := c.issues, c.style
+
 	// 统计不同状态的issue数量
-	statusCount := make(map[string]int)
-	for _, issue := range issues {
-		status := issue.GetState()
-		statusCount[status]++
-	}
+	statusItems := countIssueStatus(issues)
 
 	// 创建饼图实例
 	pie := charts.NewPie()
 	pie.SetGlobalOptions(
-		charts.WithInitializationOpts(opts.Initialization{
-			Theme:  types.ThemeWesteros,
-			Width:  "800px",
-			Height: "600px",
-		}),
+		style.initOpts("800px", "600px"),
 		charts.WithTitleOpts(opts.Title{
 			Title:    "Issues状态分布",
 			Subtitle: fmt.Sprintf("总数: %d", len(issues)),
@@ -71,11 +249,11 @@ func generateStatusChart(issues []*github.Issue, chartsDir string) error {
 	)
 
 	// 准备数据
-	items := make([]opts.PieData, 0, len(statusCount))
-	for status, count := range statusCount {
+	items := make([]opts.PieData, 0, len(statusItems))
+	for _, item := range statusItems {
 		items = append(items, opts.PieData{
-			Name:  status,
-			Value: count,
+			Name:  item.Name,
+			Value: item.Count,
 		})
 	}
 
@@ -92,7 +270,7 @@ func generateStatusChart(issues []*github.Issue, chartsDir string) error {
 		)
 
 	// 保存图表
-	f, err := os.Create(filepath.Join(chartsDir, "status_chart.html"))
+	f, err := os.Create(filepath.Join(c.dir, "status_chart.html"))
 	if err != nil {
 		return err
 	}
@@ -101,48 +279,16 @@ func generateStatusChart(issues []*github.Issue, chartsDir string) error {
 }
 
 // 生成标签分布图
-func generateLabelsChart(issues []*github.Issue, chartsDir string) error {
-	// 统计不同标签的issue数量
-	labelCount := make(map[string]int)
-	for _, issue := range issues {
-		if len(issue.Labels) == 0 {
-			labelCount["无标签"]++
-			continue
-		}
-
-		for _, label := range issue.Labels {
-			labelName := label.GetName()
-			labelCount[labelName]++
-		}
-	}
-
-	// 按数量排序标签
-	type labelItem struct {
-		Name  string
-		Count int
-	}
-	var labelItems []labelItem
-	for name, count := range labelCount {
-		labelItems = append(labelItems, labelItem{Name: name, Count: count})
-	}
-	sort.Slice(labelItems, func(i, j int) bool {
-		return labelItems[i].Count > labelItems[j].Count
-	})
+func generateLabelsChart(c *chartContext) error {
+	issues, sel, style := c.issues, c.labels, c.style
 
-	// 如果标签太多，只取前10个
-	maxLabels := 10
-	if len(labelItems) > maxLabels {
-		labelItems = labelItems[:maxLabels]
-	}
+	// 统计不同标签的issue数量
+	labelItems := countIssueLabels(issues, sel)
 
 	// 创建柱状图实例
 	bar := charts.NewBar()
 	bar.SetGlobalOptions(
-		charts.WithInitializationOpts(opts.Initialization{
-			Theme:  types.ThemeWesteros,
-			Width:  "900px",
-			Height: "500px",
-		}),
+		style.initOpts("900px", "500px"),
 		charts.WithTitleOpts(opts.Title{
 			Title:    "Issues标签分布",
 			Subtitle: fmt.Sprintf("前%d个标签", len(labelItems)),
@@ -177,7 +323,7 @@ func generateLabelsChart(issues []*github.Issue, chartsDir string) error {
 		)
 
 	// 保存图表
-	f, err := os.Create(filepath.Join(chartsDir, "labels_chart.html"))
+	f, err := os.Create(filepath.Join(c.dir, "labels_chart.html"))
 	if err != nil {
 		return err
 	}
@@ -186,55 +332,23 @@ func generateLabelsChart(issues []*github.Issue, chartsDir string) error {
 }
 
 // 生成时间趋势图
-func generateTimelineChart(issues []*github.Issue, chartsDir string) error {
-	// 按月统计issue创建数量
-	monthlyCount := make(map[string]int)
+func generateTimelineChart(c *chartContext) error {
+	issues, style := c.issues, c.style
 
-	// 找出最早和最晚的日期
-	var earliestDate, latestDate time.Time
-	for i, issue := range issues {
-		createdAt := issue.GetCreatedAt()
-		if i == 0 || createdAt.Before(earliestDate) {
-			earliestDate = createdAt.Time
-		}
-		if i == 0 || createdAt.After(latestDate) {
-			latestDate = createdAt.Time
-		}
-	}
-
-	// 生成所有月份的键
-	current := time.Date(earliestDate.Year(), earliestDate.Month(), 1, 0, 0, 0, 0, time.UTC)
-	end := time.Date(latestDate.Year(), latestDate.Month(), 1, 0, 0, 0, 0, time.UTC)
-	for !current.After(end) {
-		monthKey := current.Format("2006-01")
-		monthlyCount[monthKey] = 0
-		current = current.AddDate(0, 1, 0)
-	}
-
-	// 统计每月的issue数量
-	for _, issue := range issues {
-		monthKey := issue.GetCreatedAt().Format("2006-01")
-		monthlyCount[monthKey]++
-	}
-
-	// 按时间顺序排序月份
-	var months []string
-	for month := range monthlyCount {
-		months = append(months, month)
+	// 按月统计issue创建数量
+	months, monthlyCount := countMonthlyCreated(issues)
+	var earliestDate, latestDate string
+	if len(months) > 0 {
+		earliestDate, latestDate = months[0], months[len(months)-1]
 	}
-	sort.Strings(months)
 
 	// 创建折线图实例
 	line := charts.NewLine()
 	line.SetGlobalOptions(
-		charts.WithInitializationOpts(opts.Initialization{
-			Theme:  types.ThemeWesteros,
-			Width:  "1000px",
-			Height: "500px",
-		}),
+		style.initOpts("1000px", "500px"),
 		charts.WithTitleOpts(opts.Title{
 			Title:    "Issues创建时间趋势",
-			Subtitle: fmt.Sprintf("从 %s 到 %s", earliestDate.Format("2006-01"), latestDate.Format("2006-01")),
+			Subtitle: fmt.Sprintf("从 %s 到 %s", earliestDate, latestDate),
 		}),
 		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
 		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true)}),
@@ -259,8 +373,8 @@ func generateTimelineChart(issues []*github.Issue, chartsDir string) error {
 
 	// 准备数据
 	values := make([]opts.LineData, 0, len(months))
-	for _, month := range months {
-		values = append(values, opts.LineData{Value: monthlyCount[month]})
+	for _, count := range monthlyCount {
+		values = append(values, opts.LineData{Value: count})
 	}
 
 	// 添加数据到图表
@@ -283,7 +397,7 @@ func generateTimelineChart(issues []*github.Issue, chartsDir string) error {
 		)
 
 	// 保存图表
-	f, err := os.Create(filepath.Join(chartsDir, "timeline_chart.html"))
+	f, err := os.Create(filepath.Join(c.dir, "timeline_chart.html"))
 	if err != nil {
 		return err
 	}
@@ -291,19 +405,103 @@ func generateTimelineChart(issues []*github.Issue, chartsDir string) error {
 	return line.Render(f)
 }
 
+// 统计不同状态的issue数量
+func countIssueStatus(issues []*github.Issue) []countItem {
+	statusCount := make(map[string]int)
+	for _, issue := range issues {
+		statusCount[issue.GetState()]++
+	}
+	return sortedCounts(statusCount, 0)
+}
+
+// 统计不同标签的issue数量，没有标签的issue计入“无标签”，按数量取前TopN个
+func countIssueLabels(issues []*github.Issue, sel labelSelection) []countItem {
+	labelCount := make(map[string]int)
+	for _, issue := range issues {
+		if len(issue.Labels) == 0 {
+			labelCount["无标签"]++
+			continue
+		}
+
+		for _, labelName := range sel.issueLabels(issue) {
+			labelCount[labelName]++
+		}
+	}
+	return sortedCounts(labelCount, sel.TopN)
+}
+
+// 按月统计issue创建数量，从最早到最晚的月份连续排列，没有issue的月份数量为0
+func countMonthlyCreated(issues []*github.Issue) ([]string, []int) {
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	// 找出最早和最晚的日期
+	var earliestDate, latestDate time.Time
+	for i, issue := range issues {
+		createdAt := issue.GetCreatedAt()
+		if i == 0 || createdAt.Before(earliestDate) {
+			earliestDate = createdAt.Time
+		}
+		if i == 0 || createdAt.After(latestDate) {
+			latestDate = createdAt.Time
+		}
+	}
+
+	// 统计每月的issue数量
+	monthlyCount := make(map[string]int)
+	for _, issue := range issues {
+		monthlyCount[issue.GetCreatedAt().Format("2006-01")]++
+	}
+
+	// 生成所有月份
+	var months []string
+	var counts []int
+	current := time.Date(earliestDate.Year(), earliestDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(latestDate.Year(), latestDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	for !current.After(end) {
+		monthKey := current.Format("2006-01")
+		months = append(months, monthKey)
+		counts = append(counts, monthlyCount[monthKey])
+		current = current.AddDate(0, 1, 0)
+	}
+	return months, counts
+}
+
+// 从first到last的连续月份（格式为2006-01，包含两端），用于补全没有数据的月份
+func continuousMonths(first, last string) []string {
+	current, err := time.Parse("2006-01", first)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse("2006-01", last)
+	if err != nil {
+		return nil
+	}
+	var months []string
+	for !current.After(end) {
+		months = append(months, current.Format("2006-01"))
+		current = current.AddDate(0, 1, 0)
+	}
+	return months
+}
+
 // 生成图表索引页
-func generateChartsIndex(chartsDir string) error {
+func generateChartsIndex(chartsDir string, entries []chartIndexEntry) error {
 	page := components.NewPage()
 	page.SetLayout(components.PageFlexLayout)
 
 	// 创建HTML内容
+	var links strings.Builder
+	for _, entry := range entries {
+		links.WriteString(fmt.Sprintf(`
+            <a href="%s" style="font-size: 18px; padding: 10px; background-color: #f0f0f0; border-radius: 5px; text-decoration: none; color: #333;">%s</a>`,
+			entry.File, html.EscapeString(entry.Title)))
+	}
 	content := `
     <div style='margin: 20px; text-align: center;'>
         <h1>GitHub Issues 图表分析</h1>
-        <div style="display: flex; flex-direction: column; gap: 15px; margin-top: 30px;">
-            <a href="status_chart.html" style="font-size: 18px; padding: 10px; background-color: #f0f0f0; border-radius: 5px; text-decoration: none; color: #333;">状态分布图</a>
-            <a href="labels_chart.html" style="font-size: 18px; padding: 10px; background-color: #f0f0f0; border-radius: 5px; text-decoration: none; color: #333;">标签分布图</a>
-            <a href="timeline_chart.html" style="font-size: 18px; padding: 10px; background-color: #f0f0f0; border-radius: 5px; text-decoration: none; color: #333;">时间趋势图</a>
+        <div style="display: flex; flex-direction: column; gap: 15px; margin-top: 30px;">` + links.String() + `
         </div>
     </div>
     `
@@ -315,7 +513,7 @@ func generateChartsIndex(chartsDir string) error {
 	page.SetPageTitle("GitHub Issues 图表分析")
 
 	// 保存索引页
-	f, err := os.Create(filepath.Join(chartsDir, "index.html"))
+	f, err := os.Create(filepath.Join(chartsDir, chartsIndexFile))
 	if err != nil {
 		return err
 	}
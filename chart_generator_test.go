@@ -0,0 +1,23 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContinuousMonths(t *testing.T) {
+	tests := []struct {
+		first, last string
+		want        []string
+	}{
+		{"2024-03", "2024-03", []string{"2024-03"}},
+		{"2023-11", "2024-02", []string{"2023-11", "2023-12", "2024-01", "2024-02"}},
+		{"2024-05", "2024-03", nil},
+		{"invalid", "2024-03", nil},
+	}
+	for _, tt := range tests {
+		if got := continuousMonths(tt.first, tt.last); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("continuousMonths(%q, %q) = %v，期望 %v", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
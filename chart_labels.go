@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/google/go-github/v57/github"
+)
+
+// 标签图表默认显示的标签数量
+const defaultChartTopLabels = 10
+
+// 标签图表中统计的标签：只统计Include中的标签（为空时统计所有标签），排除Exclude中的标签，取数量最多的前TopN个
+type labelSelection struct {
+	TopN    int
+	Include []string
+	Exclude []string
+}
+
+func newLabelSelection(cfg *Config) labelSelection {
+	topN := cfg.ChartTopLabels
+	if topN <= 0 {
+		topN = defaultChartTopLabels
+	}
+	return labelSelection{TopN: topN, Include: cfg.ChartIncludeLabels, Exclude: cfg.ChartExcludeLabels}
+}
+
+func (s labelSelection) allowed(name string) bool {
+	if len(s.Include) > 0 && !containsString(s.Include, name) {
+		return false
+	}
+	return !containsString(s.Exclude, name)
+}
+
+// issue上需要统计的标签名
+func (s labelSelection) issueLabels(issue *github.Issue) []string {
+	var names []string
+	for _, label := range issue.Labels {
+		if name := label.GetName(); s.allowed(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// 使用数量最多的前TopN个标签
+func (s labelSelection) topLabels(issues []*github.Issue) []string {
+	counts := make(map[string]int)
+	for _, issue := range issues {
+		for _, name := range s.issueLabels(issue) {
+			counts[name]++
+		}
+	}
+	items := sortedCounts(counts, s.TopN)
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name
+	}
+	return names
+}
+
+// 生成标签分析图：标签共现热力图和每月标签使用趋势
+func generateLabelAnalysisChart(c *chartContext) error {
+	labels := c.labels.topLabels(c.issues)
+	if len(labels) == 0 {
+		return fmt.Errorf("%w: 没有符合条件的标签", errChartSkipped)
+	}
+	return saveChartPage(filepath.Join(c.dir, "label_analysis_chart.html"), "标签分析",
+		newLabelCooccurrenceHeatMap(c.style, c.issues, c.labels, labels), newLabelTrend(c.style, c.issues, c.labels, labels))
+}
+
+// 标签共现热力图，每个格子为同时带有两个标签的issues数量，对角线为带有该标签的issues数量
+func newLabelCooccurrenceHeatMap(style chartStyle, issues []*github.Issue, sel labelSelection, labels []string) *charts.HeatMap {
+	index := make(map[string]int, len(labels))
+	for i, name := range labels {
+		index[name] = i
+	}
+
+	matrix := make([][]int, len(labels))
+	for i := range matrix {
+		matrix[i] = make([]int, len(labels))
+	}
+	for _, issue := range issues {
+		var present []int
+		for _, name := range sel.issueLabels(issue) {
+			if i, ok := index[name]; ok {
+				present = append(present, i)
+			}
+		}
+		for _, i := range present {
+			for _, j := range present {
+				matrix[i][j]++
+			}
+		}
+	}
+
+	var data []opts.HeatMapData
+	maxCount := 0
+	for i := range labels {
+		for j := range labels {
+			data = append(data, opts.HeatMapData{Value: [3]interface{}{i, j, matrix[i][j]}})
+			if i != j && matrix[i][j] > maxCount {
+				maxCount = matrix[i][j]
+			}
+		}
+	}
+	if maxCount == 0 {
+		maxCount = 1
+	}
+
+	heatMap := charts.NewHeatMap()
+	heatMap.SetGlobalOptions(
+		style.initOpts("900px", "750px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "标签共现热力图",
+			Subtitle: fmt.Sprintf("前%d个标签同时出现在同一个issue上的次数，对角线为该标签的issues数量", len(labels)),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithGridOpts(opts.Grid{Left: "150px", Bottom: "180px"}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Type:      "category",
+			Data:      labels,
+			AxisLabel: &opts.AxisLabel{Rotate: 45, Interval: "0"},
+			SplitArea: &opts.SplitArea{Show: opts.Bool(true)},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Type:      "category",
+			Data:      labels,
+			AxisLabel: &opts.AxisLabel{Interval: "0"},
+			SplitArea: &opts.SplitArea{Show: opts.Bool(true)},
+		}),
+		// 颜色范围按非对角线的最大值设置，避免对角线的数量掩盖共现关系
+		charts.WithVisualMapOpts(opts.VisualMap{
+			Calculable: opts.Bool(true),
+			Min:        0,
+			Max:        float32(maxCount),
+			Orient:     "horizontal",
+			Left:       "center",
+			Bottom:     "10px",
+			InRange:    &opts.VisualMapInRange{Color: []string{"#f7fbff", "#6baed6", "#08306b"}},
+		}),
+	)
+	heatMap.SetXAxis(labels).
+		AddSeries("共现次数", data, charts.WithLabelOpts(opts.Label{Show: opts.Bool(true)}))
+	return heatMap
+}
+
+// 每月标签使用趋势的堆叠面积图
+func newLabelTrend(style chartStyle, issues []*github.Issue, sel labelSelection, labels []string) *charts.Line {
+	monthly := make(map[string]map[string]int)
+	for _, issue := range issues {
+		month := issue.GetCreatedAt().Format("2006-01")
+		if monthly[month] == nil {
+			monthly[month] = make(map[string]int)
+		}
+		for _, name := range sel.issueLabels(issue) {
+			monthly[month][name]++
+		}
+	}
+	months := make([]string, 0, len(monthly))
+	for month := range monthly {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+	// 补全没有issues的月份，避免折线跨过空缺的时间段
+	if len(months) > 0 {
+		months = continuousMonths(months[0], months[len(months)-1])
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		style.initOpts("1000px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "标签使用趋势",
+			Subtitle: "按issue创建月份统计",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true), Type: "scroll", Top: "40px"}),
+		charts.WithGridOpts(opts.Grid{Top: "90px"}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      "月份",
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "数量",
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+	)
+	line.SetXAxis(months)
+	for _, name := range labels {
+		values := make([]opts.LineData, len(months))
+		for i, month := range months {
+			values[i] = opts.LineData{Value: monthly[month][name]}
+		}
+		line.AddSeries(name, values,
+			charts.WithLineChartOpts(opts.LineChart{Stack: "labels", Smooth: opts.Bool(true)}),
+			charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: opts.Float(0.6)}),
+		)
+	}
+	return line
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/google/go-github/v57/github"
+)
+
+// 除未关闭的里程碑外，另外为最近关闭的几个里程碑生成燃尽图
+const recentClosedMilestones = 5
+
+// 里程碑的完成百分比
+func milestoneCompletion(m *github.Milestone) float64 {
+	total := m.GetOpenIssues() + m.GetClosedIssues()
+	if total == 0 {
+		return 0
+	}
+	return float64(m.GetClosedIssues()) * 100 / float64(total)
+}
+
+// 里程碑的截止日期，没有截止日期时返回“无”
+func milestoneDueDate(m *github.Milestone) string {
+	if m.DueOn == nil {
+		return "无"
+	}
+	return m.GetDueOn().Format("2006-01-02")
+}
+
+// 需要生成燃尽图的里程碑：所有未关闭的里程碑按截止日期排序，加上最近关闭的几个
+func burndownMilestones(milestones []*github.Milestone) []*github.Milestone {
+	var open, closed []*github.Milestone
+	for _, m := range milestones {
+		if m.GetState() == "open" {
+			open = append(open, m)
+		} else {
+			closed = append(closed, m)
+		}
+	}
+	sort.Slice(open, func(i, j int) bool {
+		// 没有截止日期的排在最后
+		if (open[i].DueOn == nil) != (open[j].DueOn == nil) {
+			return open[j].DueOn == nil
+		}
+		if open[i].DueOn != nil && !open[i].GetDueOn().Time.Equal(open[j].GetDueOn().Time) {
+			return open[i].GetDueOn().Before(open[j].GetDueOn().Time)
+		}
+		return open[i].GetNumber() < open[j].GetNumber()
+	})
+	sort.Slice(closed, func(i, j int) bool {
+		return closed[i].GetClosedAt().After(closed[j].GetClosedAt().Time)
+	})
+	if len(closed) > recentClosedMilestones {
+		closed = closed[:recentClosedMilestones]
+	}
+	return append(open, closed...)
+}
+
+// 生成里程碑进度图：所有里程碑的完成情况，以及每个里程碑的燃尽图
+func generateMilestoneChart(c *chartContext) error {
+	issues, milestones := c.issues, c.milestones
+	if len(milestones) == 0 {
+		return fmt.Errorf("%w: 没有里程碑数据", errChartSkipped)
+	}
+	items := []components.Charter{newMilestoneOverview(c.style, milestones)}
+
+	byMilestone := make(map[int][]*github.Issue)
+	for _, issue := range issues {
+		if issue.Milestone != nil {
+			byMilestone[issue.Milestone.GetNumber()] = append(byMilestone[issue.Milestone.GetNumber()], issue)
+		}
+	}
+	for _, m := range burndownMilestones(milestones) {
+		if len(byMilestone[m.GetNumber()]) == 0 {
+			continue
+		}
+		items = append(items, newMilestoneBurndown(c.style, m, byMilestone[m.GetNumber()]))
+	}
+
+	return saveChartPage(filepath.Join(c.dir, "milestone_chart.html"), "里程碑进度", items...)
+}
+
+// 所有里程碑已关闭和未关闭issues数量的堆叠柱状图，按编号排序
+func newMilestoneOverview(style chartStyle, milestones []*github.Milestone) *charts.Bar {
+	sorted := append([]*github.Milestone(nil), milestones...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetNumber() < sorted[j].GetNumber()
+	})
+
+	names := make([]string, len(sorted))
+	closedValues := make([]opts.BarData, len(sorted))
+	openValues := make([]opts.BarData, len(sorted))
+	open := 0
+	for i, m := range sorted {
+		names[i] = m.GetTitle()
+		closedValues[i] = opts.BarData{
+			Value: m.GetClosedIssues(),
+			Name:  fmt.Sprintf("完成 %.0f%%，截止日期 %s", milestoneCompletion(m), milestoneDueDate(m)),
+		}
+		openValues[i] = opts.BarData{Value: m.GetOpenIssues()}
+		if m.GetState() == "open" {
+			open++
+		}
+	}
+
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		style.initOpts("1000px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "里程碑完成情况",
+			Subtitle: fmt.Sprintf("共 %d 个里程碑，未关闭 %d 个", len(sorted), open),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true)}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      "里程碑",
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "数量",
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+	)
+	bar.SetXAxis(names).
+		AddSeries("已关闭", closedValues, charts.WithBarChartOpts(opts.BarChart{Stack: "milestone"})).
+		AddSeries("未关闭", openValues, charts.WithBarChartOpts(opts.BarChart{Stack: "milestone"}))
+	return bar
+}
+
+// 里程碑的燃尽图：每天剩余未关闭的issues数量，有截止日期时加上理想燃尽线
+// 无法获取issue加入里程碑的时间，创建早于里程碑的issue按里程碑创建时计入
+func newMilestoneBurndown(style chartStyle, m *github.Milestone, issues []*github.Issue) *charts.Line {
+	var start time.Time
+	if m.CreatedAt != nil {
+		start = periodStart(m.GetCreatedAt().Time, "day")
+	} else {
+		for _, issue := range issues {
+			if created := periodStart(issue.GetCreatedAt().Time, "day"); start.IsZero() || created.Before(start) {
+				start = created
+			}
+		}
+	}
+
+	// 实际数据截止到里程碑关闭或今天
+	actualEnd := periodStart(time.Now(), "day")
+	if m.GetState() == "closed" && m.ClosedAt != nil {
+		actualEnd = periodStart(m.GetClosedAt().Time, "day")
+	}
+	end := actualEnd
+	due := time.Time{}
+	if m.DueOn != nil {
+		due = periodStart(m.GetDueOn().Time, "day")
+		if due.After(end) {
+			end = due
+		}
+	}
+	if end.Before(start) {
+		end = start
+	}
+
+	// 每天结束时剩余的issues数量
+	remainingAt := func(day time.Time) int {
+		next := day.AddDate(0, 0, 1)
+		remaining := 0
+		for _, issue := range issues {
+			if !issue.GetCreatedAt().Before(next) {
+				continue
+			}
+			if issue.GetState() == "closed" && issue.ClosedAt != nil && issue.GetClosedAt().Before(next) {
+				continue
+			}
+			remaining++
+		}
+		return remaining
+	}
+
+	var days []string
+	var actual, ideal []opts.LineData
+	// 理想燃尽线从里程碑的全部issues数量开始
+	initial := len(issues)
+	totalDays := due.Sub(start).Hours() / 24
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format("2006-01-02"))
+		if day.After(actualEnd) {
+			actual = append(actual, opts.LineData{Value: "-"})
+		} else {
+			actual = append(actual, opts.LineData{Value: remainingAt(day)})
+		}
+		if !due.IsZero() && totalDays > 0 && !day.After(due) {
+			value := float64(initial) * (1 - day.Sub(start).Hours()/24/totalDays)
+			ideal = append(ideal, opts.LineData{Value: math.Round(value*10) / 10})
+		} else {
+			ideal = append(ideal, opts.LineData{Value: "-"})
+		}
+	}
+
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		style.initOpts("1000px", "450px"),
+		charts.WithTitleOpts(opts.Title{
+			Title: fmt.Sprintf("里程碑: %s", m.GetTitle()),
+			Subtitle: fmt.Sprintf("%s，已关闭 %d 个，未关闭 %d 个，完成 %.0f%%，截止日期 %s",
+				m.GetState(), m.GetClosedIssues(), m.GetOpenIssues(), milestoneCompletion(m), milestoneDueDate(m)),
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true), Right: "10%"}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      "日期",
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "剩余数量",
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+	)
+	line.SetXAxis(days).
+		AddSeries("剩余", actual, charts.WithAreaStyleOpts(opts.AreaStyle{Opacity: opts.Float(0.2)}))
+	if !due.IsZero() {
+		line.AddSeries("理想燃尽", ideal, charts.WithLineStyleOpts(opts.LineStyle{Type: "dashed"}))
+	}
+	return line
+}
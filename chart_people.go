@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/google/go-github/v57/github"
+)
+
+// 人员排行图中默认显示的人数
+const defaultChartTopPeople = 10
+
+// 按数量从多到少排序的计数项，数量相同时按名称排序
+type countItem struct {
+	Name  string
+	Count int
+}
+
+func sortedCounts(counts map[string]int, limit int) []countItem {
+	items := make([]countItem, 0, len(counts))
+	for name, count := range counts {
+		items = append(items, countItem{Name: name, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return items[i].Count > items[j].Count
+		}
+		return items[i].Name < items[j].Name
+	})
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// 创建横向排行柱状图，数量最多的排在最上面
+func newRankingBar(style chartStyle, title, subtitle string, items []countItem) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		style.initOpts("900px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: subtitle,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name: "数量",
+		}),
+		charts.WithGridOpts(opts.Grid{Left: "150px"}),
+	)
+
+	names := make([]string, 0, len(items))
+	values := make([]opts.BarData, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		names = append(names, items[i].Name)
+		values = append(values, opts.BarData{Value: items[i].Count})
+	}
+	bar.SetXAxis(names).
+		AddSeries("数量", values).
+		SetSeriesOptions(
+			charts.WithLabelOpts(opts.Label{
+				Show:     opts.Bool(true),
+				Position: "right",
+			}),
+		)
+	bar.XYReversal()
+	return bar
+}
+
+// 生成人员分析图：提交issues最多的用户、评论最多的用户、指派人的未关闭issues数量和每月新老提交者
+// comments为nil表示没有下载评论，此时跳过评论排行
+func generatePeopleChart(c *chartContext) error {
+	issues, comments, topN := c.issues, c.comments, c.topPeople
+	include := func(user *github.User) bool {
+		return user.GetLogin() != "" && !(c.cfg.ChartExcludeBots && isBotUser(user))
+	}
+
+	var items []components.Charter
+
+	// 提交issues最多的用户
+	reporters := make(map[string]int)
+	for _, issue := range issues {
+		if include(issue.GetUser()) {
+			reporters[issue.GetUser().GetLogin()]++
+		}
+	}
+	items = append(items, newRankingBar(c.style, "提交Issues最多的用户",
+		fmt.Sprintf("共 %d 位提交者，显示前 %d 位", len(reporters), topN),
+		sortedCounts(reporters, topN)))
+
+	// 评论最多的用户
+	if comments != nil {
+		commenters := make(map[string]int)
+		for _, issueComments := range comments {
+			for _, comment := range issueComments {
+				if include(comment.GetUser()) {
+					commenters[comment.GetUser().GetLogin()]++
+				}
+			}
+		}
+		items = append(items, newRankingBar(c.style, "评论最多的用户",
+			fmt.Sprintf("共 %d 位评论者，显示前 %d 位", len(commenters), topN),
+			sortedCounts(commenters, topN)))
+	}
+
+	// 每个指派人的未关闭issues数量
+	workload := make(map[string]int)
+	unassigned := 0
+	for _, issue := range issues {
+		if issue.GetState() != "open" {
+			continue
+		}
+		assigned := false
+		for _, assignee := range issue.Assignees {
+			if include(assignee) {
+				workload[assignee.GetLogin()]++
+				assigned = true
+			}
+		}
+		if !assigned {
+			unassigned++
+		}
+	}
+	items = append(items, newRankingBar(c.style, "指派人工作量",
+		fmt.Sprintf("未关闭issues按指派人统计，另有 %d 个未指派", unassigned),
+		sortedCounts(workload, topN)))
+
+	items = append(items, newReportersTrend(c.style, issues, include))
+
+	return saveChartPage(filepath.Join(c.dir, "people_chart.html"), "人员分析", items...)
+}
+
+// 每月首次提交issue的新用户和之前提交过issue的老用户数量
+func newReportersTrend(style chartStyle, issues []*github.Issue, include func(*github.User) bool) *charts.Bar {
+	sorted := append([]*github.Issue(nil), issues...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetCreatedAt().Before(sorted[j].GetCreatedAt().Time)
+	})
+
+	seen := make(map[string]bool)
+	newCount := make(map[string]int)
+	returningCount := make(map[string]int)
+	monthReporters := make(map[string]map[string]bool)
+	var months []string
+	for _, issue := range sorted {
+		if !include(issue.GetUser()) {
+			continue
+		}
+		login := issue.GetUser().GetLogin()
+		month := issue.GetCreatedAt().Format("2006-01")
+		if monthReporters[month] == nil {
+			monthReporters[month] = make(map[string]bool)
+			months = append(months, month)
+		}
+		// 同一用户在一个月内只统计一次
+		if monthReporters[month][login] {
+			continue
+		}
+		monthReporters[month][login] = true
+		if seen[login] {
+			returningCount[month]++
+		} else {
+			newCount[month]++
+			seen[login] = true
+		}
+	}
+
+	// 补全没有新提交issue的月份，避免时间轴被压缩
+	if len(months) > 0 {
+		months = continuousMonths(months[0], months[len(months)-1])
+	}
+
+	newValues := make([]opts.BarData, len(months))
+	returningValues := make([]opts.BarData, len(months))
+	for i, month := range months {
+		newValues[i] = opts.BarData{Value: newCount[month]}
+		returningValues[i] = opts.BarData{Value: returningCount[month]}
+	}
+
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		style.initOpts("1000px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    "每月新老提交者",
+			Subtitle: "新提交者为当月首次提交issue的用户",
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true)}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      "月份",
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "人数",
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+	)
+	bar.SetXAxis(months).
+		AddSeries("新提交者", newValues, charts.WithBarChartOpts(opts.BarChart{Stack: "reporters"})).
+		AddSeries("老提交者", returningValues, charts.WithBarChartOpts(opts.BarChart{Stack: "reporters"}))
+	return bar
+}
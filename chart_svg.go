@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// SVG图片的尺寸
+const (
+	svgWidth  = 800
+	svgHeight = 450
+)
+
+// 与HTML图表westeros主题一致的配色
+var svgPalette = []string{"#516b91", "#59c4e6", "#edafda", "#93b7e3", "#a5e7f0", "#cbb0e3"}
+
+// 另存为SVG图片的图表，文件名与对应的HTML图表只有扩展名不同，如 status_chart.html 对应 status_chart.svg
+var chartImages = []chartIndexEntry{
+	{"status_chart.svg", "状态分布图"},
+	{"labels_chart.svg", "标签分布图"},
+	{"timeline_chart.svg", "时间趋势图"},
+}
+
+// 将状态、标签和时间趋势图渲染为SVG图片，不依赖浏览器，便于嵌入Markdown或幻灯片
+func generateChartImages(issues []*github.Issue, sel labelSelection, chartsDir string) error {
+	images := map[string]string{
+		"status_chart.svg":   renderStatusSVG(issues),
+		"labels_chart.svg":   renderLabelsSVG(issues, sel),
+		"timeline_chart.svg": renderTimelineSVG(issues),
+	}
+	for _, image := range chartImages {
+		if err := os.WriteFile(filepath.Join(chartsDir, image.File), []byte(images[image.File]), 0644); err != nil {
+			return fmt.Errorf("保存图片 %s 失败: %w", image.File, err)
+		}
+	}
+	return nil
+}
+
+// 在已生成的总结文件中插入图表图片章节，图片生成成功后调用，避免总结中出现失效的图片链接
+func embedChartImages(summaryPath string) error {
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return fmt.Errorf("读取总结文件失败: %w", err)
+	}
+	content := string(data)
+	section := chartImagesMarkdown()
+	if strings.Contains(content, section) {
+		return nil
+	}
+
+	idx := strings.Index(content, summaryIssuesHeading)
+	if idx < 0 {
+		idx = len(content)
+	}
+	content = content[:idx] + section + content[idx:]
+	if err := os.WriteFile(summaryPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("保存总结文件失败: %w", err)
+	}
+	return nil
+}
+
+// 总结文件中引用图表图片的章节，图片路径相对于输出目录
+func chartImagesMarkdown() string {
+	var sb strings.Builder
+	sb.WriteString("## 图表\n\n")
+	for _, image := range chartImages {
+		sb.WriteString(fmt.Sprintf("![%s](charts/%s)\n\n", image.Title, image.File))
+	}
+	return sb.String()
+}
+
+// 构建SVG文档，内容为已经生成的图形元素
+type svgCanvas struct {
+	sb strings.Builder
+}
+
+func newSVGCanvas(title, subtitle string) *svgCanvas {
+	c := &svgCanvas{}
+	c.sb.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		svgWidth, svgHeight, svgWidth, svgHeight))
+	c.sb.WriteString(fmt.Sprintf(`<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", svgWidth, svgHeight))
+	c.text(20, 30, title, 18, "start", "#333333", 0)
+	if subtitle != "" {
+		c.text(20, 52, subtitle, 12, "start", "#888888", 0)
+	}
+	return c
+}
+
+func (c *svgCanvas) text(x, y float64, s string, size int, anchor, color string, rotate float64) {
+	transform := ""
+	if rotate != 0 {
+		transform = fmt.Sprintf(` transform="rotate(%.0f %.1f %.1f)"`, rotate, x, y)
+	}
+	c.sb.WriteString(fmt.Sprintf(`<text x="%.1f" y="%.1f" font-size="%d" text-anchor="%s" fill="%s"%s>%s</text>`+"\n",
+		x, y, size, anchor, color, transform, html.EscapeString(s)))
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2 float64, color string) {
+	c.sb.WriteString(fmt.Sprintf(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", x1, y1, x2, y2, color))
+}
+
+func (c *svgCanvas) String() string {
+	return c.sb.String() + "</svg>\n"
+}
+
+// 坐标轴的最大刻度和刻度间隔，刻度取1、2、5的倍数
+func svgAxisScale(max int) (float64, float64) {
+	if max <= 0 {
+		return 1, 1
+	}
+	raw := float64(max) / 5
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := magnitude
+	for _, m := range []float64{1, 2, 5, 10} {
+		if m*magnitude >= raw {
+			step = m * magnitude
+			break
+		}
+	}
+	if step < 1 {
+		step = 1
+	}
+	return math.Ceil(float64(max)/step) * step, step
+}
+
+// 绘图区域的边界
+type svgPlot struct {
+	left, top, right, bottom float64
+	max                      float64
+}
+
+// 绘制Y轴刻度和网格线，返回绘图区域
+func (c *svgCanvas) valueAxis(max int) svgPlot {
+	p := svgPlot{left: 60, top: 80, right: svgWidth - 30, bottom: svgHeight - 90}
+	axisMax, step := svgAxisScale(max)
+	p.max = axisMax
+	for v := 0.0; v <= axisMax; v += step {
+		y := p.y(v)
+		c.line(p.left, y, p.right, y, "#eeeeee")
+		c.text(p.left-8, y+4, fmt.Sprintf("%.0f", v), 11, "end", "#666666", 0)
+	}
+	c.line(p.left, p.bottom, p.right, p.bottom, "#999999")
+	return p
+}
+
+func (p svgPlot) y(v float64) float64 {
+	return p.bottom - v/p.max*(p.bottom-p.top)
+}
+
+// 状态分布环形图
+func renderStatusSVG(issues []*github.Issue) string {
+	items := countIssueStatus(issues)
+	c := newSVGCanvas("Issues状态分布", fmt.Sprintf("总数: %d", len(issues)))
+
+	cx, cy, outer, inner := 300.0, 250.0, 150.0, 85.0
+	angle := -math.Pi / 2
+	for i, item := range items {
+		color := svgPalette[i%len(svgPalette)]
+		share := float64(item.Count) / float64(len(issues))
+		if share >= 1 {
+			// 只有一种状态时画完整的圆环
+			c.sb.WriteString(fmt.Sprintf(`<circle cx="%.1f" cy="%.1f" r="%.1f" fill="none" stroke="%s" stroke-width="%.1f"/>`+"\n",
+				cx, cy, (outer+inner)/2, color, outer-inner))
+		} else {
+			end := angle + share*2*math.Pi
+			large := 0
+			if share > 0.5 {
+				large = 1
+			}
+			c.sb.WriteString(fmt.Sprintf(`<path d="M %.2f %.2f A %.1f %.1f 0 %d 1 %.2f %.2f L %.2f %.2f A %.1f %.1f 0 %d 0 %.2f %.2f Z" fill="%s"/>`+"\n",
+				cx+outer*math.Cos(angle), cy+outer*math.Sin(angle), outer, outer, large, cx+outer*math.Cos(end), cy+outer*math.Sin(end),
+				cx+inner*math.Cos(end), cy+inner*math.Sin(end), inner, inner, large, cx+inner*math.Cos(angle), cy+inner*math.Sin(angle),
+				color))
+			angle = end
+		}
+
+		// 图例
+		y := 120 + float64(i)*28
+		c.sb.WriteString(fmt.Sprintf(`<rect x="520" y="%.1f" width="14" height="14" fill="%s"/>`+"\n", y-11, color))
+		c.text(542, y, fmt.Sprintf("%s: %d (%.1f%%)", item.Name, item.Count, share*100), 14, "start", "#333333", 0)
+	}
+	return c.String()
+}
+
+// 标签分布柱状图
+func renderLabelsSVG(issues []*github.Issue, sel labelSelection) string {
+	items := countIssueLabels(issues, sel)
+	c := newSVGCanvas("Issues标签分布", fmt.Sprintf("前%d个标签", len(items)))
+
+	max := 0
+	for _, item := range items {
+		if item.Count > max {
+			max = item.Count
+		}
+	}
+	p := c.valueAxis(max)
+	if len(items) == 0 {
+		return c.String()
+	}
+
+	slot := (p.right - p.left) / float64(len(items))
+	width := slot * 0.6
+	for i, item := range items {
+		x := p.left + slot*float64(i) + (slot-width)/2
+		y := p.y(float64(item.Count))
+		c.sb.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n",
+			x, y, width, p.bottom-y, svgPalette[0]))
+		c.text(x+width/2, y-5, fmt.Sprintf("%d", item.Count), 11, "middle", "#333333", 0)
+		c.text(x+width/2, p.bottom+14, item.Name, 11, "end", "#333333", -45)
+	}
+	return c.String()
+}
+
+// 按月创建数量折线图
+func renderTimelineSVG(issues []*github.Issue) string {
+	months, counts := countMonthlyCreated(issues)
+	subtitle := ""
+	if len(months) > 0 {
+		subtitle = fmt.Sprintf("从 %s 到 %s", months[0], months[len(months)-1])
+	}
+	c := newSVGCanvas("Issues创建时间趋势", subtitle)
+
+	max := 0
+	for _, count := range counts {
+		if count > max {
+			max = count
+		}
+	}
+	p := c.valueAxis(max)
+	if len(months) == 0 {
+		return c.String()
+	}
+
+	step := (p.right - p.left) / float64(len(months))
+	// 月份较多时间隔显示坐标轴标签
+	labelEvery := int(math.Ceil(float64(len(months)) / 24))
+	points := make([]string, len(months))
+	for i, count := range counts {
+		x := p.left + step*(float64(i)+0.5)
+		y := p.y(float64(count))
+		points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		if i%labelEvery == 0 {
+			c.text(x, p.bottom+14, months[i], 11, "end", "#333333", -45)
+		}
+	}
+	c.sb.WriteString(fmt.Sprintf(`<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+		strings.Join(points, " "), svgPalette[0]))
+	for _, point := range points {
+		xy := strings.Split(point, ",")
+		c.sb.WriteString(fmt.Sprintf(`<circle cx="%s" cy="%s" r="3" fill="%s"/>`+"\n", xy[0], xy[1], svgPalette[0]))
+	}
+	return c.String()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbedChartImages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary.md")
+	original := "# 总结\n\n## AI分析\n\n内容\n\n" + summaryIssuesHeading + "| 编号 |\n"
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复调用时只插入一次
+	for i := 0; i < 2; i++ {
+		if err := embedChartImages(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	if strings.Count(content, "## 图表") != 1 {
+		t.Fatalf("图表章节数量不为1:\n%s", content)
+	}
+	if strings.Index(content, "## 图表") > strings.Index(content, summaryIssuesHeading) {
+		t.Errorf("图表章节应在issues列表之前:\n%s", content)
+	}
+	for _, image := range chartImages {
+		if !strings.Contains(content, "charts/"+image.File) {
+			t.Errorf("缺少图片 %s", image.File)
+		}
+	}
+}
+
+func TestGenerateAISummaryLeavesOutChartImages(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	cfg.ChartEnable, cfg.ChartImages = true, true
+
+	if err := generateAISummary(newLongIssues(), dir, "owner/repo", cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "summary.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), ".svg") {
+		t.Errorf("图片生成前总结中不应有图片链接:\n%s", data)
+	}
+}
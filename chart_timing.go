@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/charts"
+	"github.com/go-echarts/go-echarts/v2/components"
+	"github.com/go-echarts/go-echarts/v2/opts"
+	"github.com/google/go-github/v57/github"
+)
+
+// 时长分布的分组，每组包含不超过Max的时长
+type durationBucket struct {
+	Label string
+	Max   time.Duration
+}
+
+const durationDay = 24 * time.Hour
+
+// 首次响应时间的分组
+var responseTimeBuckets = []durationBucket{
+	{"1小时内", time.Hour},
+	{"1-24小时", durationDay},
+	{"1-3天", 3 * durationDay},
+	{"3-7天", 7 * durationDay},
+	{"7-30天", 30 * durationDay},
+	{"30天以上", math.MaxInt64},
+}
+
+// 关闭耗时的分组
+var resolutionTimeBuckets = []durationBucket{
+	{"1天内", durationDay},
+	{"1-7天", 7 * durationDay},
+	{"7-30天", 30 * durationDay},
+	{"1-3个月", 90 * durationDay},
+	{"3-12个月", 365 * durationDay},
+	{"1年以上", math.MaxInt64},
+}
+
+// 未关闭issues存在时长的分组
+var openAgeBuckets = []durationBucket{
+	{"7天内", 7 * durationDay},
+	{"7-30天", 30 * durationDay},
+	{"1-3个月", 90 * durationDay},
+	{"3-6个月", 180 * durationDay},
+	{"6-12个月", 365 * durationDay},
+	{"1年以上", math.MaxInt64},
+}
+
+// 统计每个分组中的时长数量
+func countDurationBuckets(durations []time.Duration, buckets []durationBucket) []opts.BarData {
+	counts := make([]int, len(buckets))
+	for _, d := range durations {
+		for i, bucket := range buckets {
+			if d <= bucket.Max {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	values := make([]opts.BarData, len(buckets))
+	for i, count := range counts {
+		values[i] = opts.BarData{Value: count}
+	}
+	return values
+}
+
+func durationBucketLabels(buckets []durationBucket) []string {
+	labels := make([]string, len(buckets))
+	for i, bucket := range buckets {
+		labels[i] = bucket.Label
+	}
+	return labels
+}
+
+// 计算已排序数据的百分位数，使用线性插值
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// 判断评论者是否为机器人账号
+func isBotUser(user *github.User) bool {
+	return user.GetType() == "Bot" || strings.HasSuffix(user.GetLogin(), "[bot]")
+}
+
+// issue的首次响应时间：从创建到第一条非作者、非机器人评论的时长
+func firstResponseTime(issue *github.Issue, comments []*github.IssueComment) (time.Duration, bool) {
+	author := issue.GetUser().GetLogin()
+	var first time.Time
+	for _, comment := range comments {
+		if comment.GetUser().GetLogin() == author || isBotUser(comment.GetUser()) {
+			continue
+		}
+		if createdAt := comment.GetCreatedAt().Time; first.IsZero() || createdAt.Before(first) {
+			first = createdAt
+		}
+	}
+	if first.IsZero() {
+		return 0, false
+	}
+	return first.Sub(issue.GetCreatedAt().Time), true
+}
+
+// 按月计算百分位数，返回月份和每个百分位数的序列
+func monthlyPercentiles(values map[string][]float64, ps []float64) ([]string, [][]opts.LineData) {
+	months := make([]string, 0, len(values))
+	for month := range values {
+		months = append(months, month)
+	}
+	sort.Strings(months)
+
+	series := make([][]opts.LineData, len(ps))
+	for _, month := range months {
+		sorted := append([]float64(nil), values[month]...)
+		sort.Float64s(sorted)
+		for i, p := range ps {
+			series[i] = append(series[i], opts.LineData{Value: math.Round(percentile(sorted, p)*10) / 10})
+		}
+	}
+	return months, series
+}
+
+// 创建时长分布柱状图
+func newDurationHistogram(style chartStyle, title, subtitle string, buckets []durationBucket, values []opts.BarData) *charts.Bar {
+	bar := charts.NewBar()
+	bar.SetGlobalOptions(
+		style.initOpts("900px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: subtitle,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true)}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(false)}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: "数量",
+		}),
+	)
+	bar.SetXAxis(durationBucketLabels(buckets)).
+		AddSeries("数量", values).
+		SetSeriesOptions(
+			charts.WithLabelOpts(opts.Label{
+				Show:     opts.Bool(true),
+				Position: "top",
+			}),
+		)
+	return bar
+}
+
+// 创建按月百分位数折线图
+func newPercentileLine(style chartStyle, title, subtitle, unit string, months []string, names []string, series [][]opts.LineData) *charts.Line {
+	line := charts.NewLine()
+	line.SetGlobalOptions(
+		style.initOpts("1000px", "500px"),
+		charts.WithTitleOpts(opts.Title{
+			Title:    title,
+			Subtitle: subtitle,
+		}),
+		charts.WithTooltipOpts(opts.Tooltip{Show: opts.Bool(true), Trigger: "axis"}),
+		charts.WithLegendOpts(opts.Legend{Show: opts.Bool(true)}),
+		charts.WithXAxisOpts(opts.XAxis{
+			Name:      "月份",
+			AxisLabel: &opts.AxisLabel{Rotate: 45},
+		}),
+		charts.WithYAxisOpts(opts.YAxis{
+			Name: unit,
+		}),
+		charts.WithDataZoomOpts(opts.DataZoom{
+			Type:  "slider",
+			Start: 0,
+			End:   100,
+		}),
+	)
+	line.SetXAxis(months)
+	for i, name := range names {
+		line.AddSeries(name, series[i])
+	}
+	line.SetSeriesOptions(charts.WithLineChartOpts(opts.LineChart{Smooth: opts.Bool(true)}))
+	return line
+}
+
+// 将多个图表保存到同一个页面
+func saveChartPage(path, title string, items ...components.Charter) error {
+	page := components.NewPage()
+	page.SetLayout(components.PageFlexLayout)
+	page.SetPageTitle(title)
+	page.AddCharts(items...)
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return page.Render(f)
+}
+
+// 生成首次响应时间图：响应时间分布和按创建月份的响应时间中位数
+func generateResponseTimeChart(c *chartContext) error {
+	issues, comments := c.issues, c.comments
+	if comments == nil {
+		return fmt.Errorf("%w: 未下载评论", errChartSkipped)
+	}
+
+	var durations []time.Duration
+	monthly := make(map[string][]float64)
+	noResponse := 0
+	for _, issue := range issues {
+		d, ok := firstResponseTime(issue, comments[issue.GetNumber()])
+		if !ok {
+			noResponse++
+			continue
+		}
+		durations = append(durations, d)
+		month := issue.GetCreatedAt().Format("2006-01")
+		monthly[month] = append(monthly[month], d.Hours())
+	}
+
+	buckets := append(responseTimeBuckets[:len(responseTimeBuckets):len(responseTimeBuckets)], durationBucket{Label: "未响应"})
+	values := append(countDurationBuckets(durations, responseTimeBuckets), opts.BarData{Value: noResponse})
+	histogram := newDurationHistogram(c.style, "首次响应时间分布",
+		fmt.Sprintf("首次响应为第一条非作者、非机器人的评论，已响应 %d 个，未响应 %d 个", len(durations), noResponse),
+		buckets, values)
+
+	months, series := monthlyPercentiles(monthly, []float64{50, 90})
+	line := newPercentileLine(c.style, "首次响应时间趋势", "按issue创建月份统计", "小时", months, []string{"中位数", "P90"}, series)
+
+	return saveChartPage(filepath.Join(c.dir, "response_time_chart.html"), "首次响应时间", histogram, line)
+}
+
+// 生成关闭耗时图：关闭耗时分布和按关闭月份的耗时百分位数
+func generateResolutionTimeChart(c *chartContext) error {
+	issues := c.issues
+	var durations []time.Duration
+	var all []float64
+	monthly := make(map[string][]float64)
+	for _, issue := range issues {
+		if issue.GetState() != "closed" || issue.ClosedAt == nil {
+			continue
+		}
+		d := issue.GetClosedAt().Sub(issue.GetCreatedAt().Time)
+		durations = append(durations, d)
+		all = append(all, d.Hours()/24)
+		month := issue.GetClosedAt().Format("2006-01")
+		monthly[month] = append(monthly[month], d.Hours()/24)
+	}
+	sort.Float64s(all)
+
+	subtitle := fmt.Sprintf("已关闭 %d 个", len(durations))
+	if len(all) > 0 {
+		subtitle += fmt.Sprintf("，中位数 %.1f 天，P90 %.1f 天", percentile(all, 50), percentile(all, 90))
+	}
+	histogram := newDurationHistogram(c.style, "关闭耗时分布", subtitle, resolutionTimeBuckets,
+		countDurationBuckets(durations, resolutionTimeBuckets))
+
+	months, series := monthlyPercentiles(monthly, []float64{50, 75, 90})
+	line := newPercentileLine(c.style, "关闭耗时趋势", "按关闭月份统计", "天", months, []string{"中位数", "P75", "P90"}, series)
+
+	return saveChartPage(filepath.Join(c.dir, "resolution_time_chart.html"), "关闭耗时", histogram, line)
+}
+
+// 生成未关闭issues的存在时长分布图
+func generateOpenAgeChart(c *chartContext) error {
+	issues := c.issues
+	now := time.Now()
+	var ages []time.Duration
+	for _, issue := range issues {
+		if issue.GetState() == "open" {
+			ages = append(ages, now.Sub(issue.GetCreatedAt().Time))
+		}
+	}
+
+	histogram := newDurationHistogram(c.style, "未关闭Issues存在时长",
+		fmt.Sprintf("未关闭 %d 个，统计于 %s", len(ages), now.Format("2006-01-02")),
+		openAgeBuckets, countDurationBuckets(ages, openAgeBuckets))
+	return saveChartPage(filepath.Join(c.dir, "open_age_chart.html"), "未关闭Issues存在时长", histogram)
+}
@@ -15,12 +15,33 @@ type Config struct {
 	// AI API token
 	AIToken string
 
+	// AI服务提供方: openai, azure, ollama, anthropic, googleai, mock
+	AIProvider string
+
 	// AI Model
 	AIModel string
 
-	// AI Base URL
+	// AI Base URL，用于OpenAI兼容接口
 	AIBaseURL string
 
+	// Ollama服务地址
+	OllamaServerURL string
+
+	// Anthropic API地址，为空时使用官方地址
+	AnthropicBaseURL string
+
+	// Azure OpenAI资源地址，使用Azure时AI Model为部署名称
+	AzureEndpoint string
+
+	// Azure OpenAI API版本
+	AzureAPIVersion string
+
+	// mock模型的应答脚本文件，为空时原样返回提示词
+	MockFixtures string
+
+	// mock模型记录收到的提示词的文件，为空时不记录
+	MockRecordFile string
+
 	// 是否下载issue评论
 	CommentEnable bool
 
@@ -30,11 +51,111 @@ type Config struct {
 	// 是否生成图表
 	ChartEnable bool
 
+	// 需要生成的图表，为空时生成所有图表
+	ChartList []string
+
+	// 图表主题，默认westeros
+	ChartTheme string
+
+	// 图表宽度，如 1200px，为空时使用每个图表的默认宽度
+	ChartWidth string
+
+	// 图表高度，如 600px，为空时使用每个图表的默认高度
+	ChartHeight string
+
+	// 积压趋势图的统计周期: day, week, month, quarter
+	ChartGranularity string
+
+	// 活动时间分布图使用的时区，如 Asia/Shanghai，为空时使用本地时区
+	ChartTimezone string
+
+	// 人员分析图和活动时间分布图中是否排除机器人账号
+	ChartExcludeBots bool
+
+	// 标签图表中显示的标签数量
+	ChartTopLabels int
+
+	// 人员分析图中显示的人数
+	ChartTopPeople int
+
+	// 标签图表只统计这些标签，为空时统计所有标签
+	ChartIncludeLabels []string
+
+	// 标签图表中不统计的标签
+	ChartExcludeLabels []string
+
+	// 是否将状态、标签和时间趋势图另存为SVG图片，并嵌入AI分析总结
+	ChartImages bool
+
+	// 是否使用AI逐个分析issues
+	AIIssueEnable bool
+
+	// 是否为评论较多的issue生成AI讨论摘要
+	DiscussionSummaryEnable bool
+
+	// 评论数超过该值时才生成讨论摘要
+	DiscussionMinComments int
+
+	// stale报告和健康报告中超过该天数没有更新的issue视为不活跃
+	StaleDays int
+
 	// 指定输出目录
 	OutputDir string
 
 	// AI分析总结文件名
 	SummaryFile string
+
+	// 分批分析时每批提示词的token上限
+	AIChunkTokens int
+
+	// 单次运行AI分析最多消耗的token数，0表示不限制
+	AIMaxTotalTokens int
+
+	// 同时进行的AI请求数
+	AIConcurrency int
+
+	// 是否在输出目录中缓存AI应答，提示词相同时不再重复请求
+	AICacheEnable bool
+
+	// 单次AI请求的超时时间（秒）
+	AITimeout int
+
+	// AI请求遇到限流、服务端错误或超时时的重试次数
+	AIMaxRetries int
+
+	// 是否将AI总结边生成边输出到控制台和文件
+	AIStream bool
+
+	// 每百万输入/输出token的单价，用于估算费用
+	AIPromptPrice     float64
+	AICompletionPrice float64
+
+	// 提示词模板文件路径，为空时使用报告风格的默认模板
+	PromptTemplate string
+
+	// 报告风格: summary, release-notes, weekly-triage, health
+	ReportStyle string
+
+	// 是否要求AI以JSON格式返回，并渲染为固定结构的报告章节
+	AIJSONOutput bool
+
+	// Embedding Model，用于相似issue检索和重复检测
+	EmbeddingModel string
+
+	// Embedding Base URL，为空时使用AI Base URL
+	EmbeddingBaseURL string
+
+	// 相似度阈值，余弦相似度不低于该值的issues视为相似
+	SimilarityThreshold float64
+
+	// 是否对导出的Markdown文件脱敏，发送给AI的内容总是脱敏
+	RedactExport bool
+
+	// 用户自定义的脱敏规则
+	RedactRules []RedactRule
+
+	// 禁用的内置脱敏规则名
+	RedactDisabledRules []string
 }
 
 // LoadConfig 从指定路径加载配置文件
@@ -53,20 +174,80 @@ func LoadConfig(filePath string) (*Config, error) {
 
 	conf.SetConfigName(split[len(split)-1])
 	conf.SetConfigType("toml")
+	conf.SetDefault("aiCacheEnable", true)
+	conf.SetDefault("aiMaxRetries", defaultAIMaxRetries)
+	conf.SetDefault("chartExcludeBots", true)
 	conf.AddConfigPath(path)
 	if err := conf.ReadInConfig(); err != nil {
 		panic(fmt.Errorf("fatal error config file: %w", err))
 	}
 
+	var redactRules []RedactRule
+	if err := conf.UnmarshalKey("redactRules", &redactRules); err != nil {
+		return nil, fmt.Errorf("解析脱敏规则失败: %w", err)
+	}
+
 	return &Config{
 		GitHubToken:   conf.GetString("gitHubToken"),
 		AIToken:       conf.GetString("aiToken"),
+		AIProvider:    conf.GetString("aiProvider"),
 		AIModel:       conf.GetString("aiModel"),
 		AIBaseURL:     conf.GetString("aiBaseURL"),
 		CommentEnable: conf.GetBool("commentEnable"),
 		AiEnable:      conf.GetBool("aiEnable"),
 		ChartEnable:   conf.GetBool("chartEnable"),
-		OutputDir:     conf.GetString("outputDir"),
-		SummaryFile:   conf.GetString("summaryFile"),
+		AIIssueEnable: conf.GetBool("aiIssueEnable"),
+
+		ChartList:        conf.GetStringSlice("chartList"),
+		ChartTheme:       conf.GetString("chartTheme"),
+		ChartWidth:       conf.GetString("chartWidth"),
+		ChartHeight:      conf.GetString("chartHeight"),
+		ChartGranularity: conf.GetString("chartGranularity"),
+		ChartTimezone:    conf.GetString("chartTimezone"),
+		ChartExcludeBots: conf.GetBool("chartExcludeBots"),
+
+		ChartTopLabels:     conf.GetInt("chartTopLabels"),
+		ChartTopPeople:     conf.GetInt("chartTopPeople"),
+		ChartIncludeLabels: conf.GetStringSlice("chartIncludeLabels"),
+		ChartExcludeLabels: conf.GetStringSlice("chartExcludeLabels"),
+		ChartImages:        conf.GetBool("chartImages"),
+
+		DiscussionSummaryEnable: conf.GetBool("discussionSummaryEnable"),
+		DiscussionMinComments:   conf.GetInt("discussionMinComments"),
+
+		StaleDays: conf.GetInt("staleDays"),
+
+		OutputDir:   conf.GetString("outputDir"),
+		SummaryFile: conf.GetString("summaryFile"),
+
+		OllamaServerURL:  conf.GetString("ollamaServerURL"),
+		AnthropicBaseURL: conf.GetString("anthropicBaseURL"),
+		AzureEndpoint:    conf.GetString("azureEndpoint"),
+		AzureAPIVersion:  conf.GetString("azureAPIVersion"),
+		MockFixtures:     conf.GetString("mockFixtures"),
+		MockRecordFile:   conf.GetString("mockRecordFile"),
+
+		AIChunkTokens:    conf.GetInt("aiChunkTokens"),
+		AIMaxTotalTokens: conf.GetInt("aiMaxTotalTokens"),
+		AIConcurrency:    conf.GetInt("aiConcurrency"),
+		AICacheEnable:    conf.GetBool("aiCacheEnable"),
+		AITimeout:        conf.GetInt("aiTimeout"),
+		AIMaxRetries:     conf.GetInt("aiMaxRetries"),
+		AIStream:         conf.GetBool("aiStream"),
+
+		AIPromptPrice:     conf.GetFloat64("aiPromptPrice"),
+		AICompletionPrice: conf.GetFloat64("aiCompletionPrice"),
+
+		PromptTemplate: conf.GetString("promptTemplate"),
+		ReportStyle:    conf.GetString("reportStyle"),
+		AIJSONOutput:   conf.GetBool("aiJSONOutput"),
+
+		EmbeddingModel:      conf.GetString("embeddingModel"),
+		EmbeddingBaseURL:    conf.GetString("embeddingBaseURL"),
+		SimilarityThreshold: conf.GetFloat64("similarityThreshold"),
+
+		RedactExport:        conf.GetBool("redactExport"),
+		RedactRules:         redactRules,
+		RedactDisabledRules: conf.GetStringSlice("redactDisabledRules"),
 	}, nil
 }
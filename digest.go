@@ -0,0 +1,435 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// 讨论最多的issues默认显示的数量
+	defaultDigestTop = 10
+
+	// 生成AI概述时报告内容在提示词中的token上限
+	digestPromptMaxTokens = 6000
+)
+
+// 周期报告支持的周期及其长度
+var digestPeriods = map[string]func(end time.Time) time.Time{
+	"week":  func(end time.Time) time.Time { return end.AddDate(0, 0, -7) },
+	"month": func(end time.Time) time.Time { return end.AddDate(0, -1, 0) },
+}
+
+// 周期内讨论较多的issue
+type digestDiscussion struct {
+	Issue    *github.Issue
+	Comments int
+}
+
+// 周期内某个标签的变化，记录被添加和移除该标签的issue编号
+type digestLabelChange struct {
+	Name    string
+	Added   []int
+	Removed []int
+}
+
+// 周期报告的内容
+type digest struct {
+	Title      string
+	Start, End time.Time
+	StaleDays  int
+
+	Opened    []*github.Issue
+	Closed    []*github.Issue
+	Discussed []digestDiscussion
+	NewStale  []*github.Issue
+	OpenCount int
+
+	// 周期内的评论总数
+	CommentCount int
+
+	// 为nil表示获取标签变化失败
+	LabelChanges []*digestLabelChange
+}
+
+// digest子命令：生成指定周期内新建、关闭、讨论最多、新增stale的issues和标签变化的报告
+func runDigest(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("digest", flag.ExitOnError)
+	period := fs.String("period", "week", "报告周期: week, month，截止到今天")
+	since := fs.String("since", "", "起始日期（包含），格式: 2006-01-02，指定后不使用 -period")
+	until := fs.String("until", "", "结束日期（包含），格式: 2006-01-02，默认为今天")
+	top := fs.Int("top", defaultDigestTop, "讨论最多的issues显示的数量")
+	output := fs.String("o", "", "输出文件路径，默认为导出issues的目录下的 digest_起始日期_结束日期.md")
+	noAI := fs.Bool("noAI", false, "不使用AI，报告中不包含AI撰写的概述")
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file digest [选项] <仓库地址>")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("未提供仓库地址")
+	}
+	periodStartOf, ok := digestPeriods[*period]
+	if !ok {
+		return fmt.Errorf("不支持的报告周期: %s，可选值: week, month", *period)
+	}
+
+	start, end, err := parseDateRange(*since, *until)
+	if err != nil {
+		return err
+	}
+	if end.IsZero() {
+		end = periodStart(time.Now(), "day").AddDate(0, 0, 1)
+	}
+	if start.IsZero() {
+		start = periodStartOf(end)
+	}
+	if !start.Before(end) {
+		return fmt.Errorf("起始日期不能晚于结束日期")
+	}
+
+	staleDays := cfg.StaleDays
+	if staleDays <= 0 {
+		staleDays = defaultStaleDays
+	}
+
+	owner, repo, err := resolveRepo(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	client := createGitHubClient(cfg.GitHubToken)
+
+	fmt.Printf("正在获取仓库 %s/%s 在 %s 至 %s 期间的issues...\n", owner, repo,
+		start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02"))
+	d, err := collectDigest(client, owner, repo, start, end, staleDays, *top)
+	if err != nil {
+		return err
+	}
+	d.Title = fmt.Sprintf("%s/%s", owner, repo)
+	fmt.Printf("新建 %d 个，关闭 %d 个，新增stale %d 个\n", len(d.Opened), len(d.Closed), len(d.NewStale))
+
+	report := formatDigest(d)
+	dir := exportDir(cfg, owner, repo)
+
+	// 有AI配置时在报告开头加上AI撰写的概述
+	if !*noAI {
+		if err := validateAIConfig(cfg); err != nil {
+			log.Printf("警告: %v，生成不含AI概述的报告", err)
+		} else if narrative, err := generateDigestNarrative(cfg, dir, d, report); err != nil {
+			log.Printf("AI撰写概述失败: %v，生成不含AI概述的报告", err)
+		} else {
+			report = insertDigestNarrative(report, narrative)
+		}
+	}
+
+	path := *output
+	if path == "" {
+		path = filepath.Join(dir, fmt.Sprintf("digest_%s_%s.md",
+			start.Format("2006-01-02"), end.AddDate(0, 0, -1).Format("2006-01-02")))
+	}
+	if parent := filepath.Dir(path); parent != "." {
+		if err := os.MkdirAll(parent, 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %w", err)
+		}
+	}
+	// 导出文件脱敏时，报告中的标题和AI概述同样脱敏
+	r, err := newExportRedactor(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(r.redact(report, filepath.Base(path))), 0644); err != nil {
+		return fmt.Errorf("保存周期报告失败: %w", err)
+	}
+	fmt.Printf("周期报告已保存到: %s\n", path)
+	if runAIUsage.Requests > 0 {
+		fmt.Println(runAIUsage.String(cfg))
+	}
+	return nil
+}
+
+// 获取生成周期报告所需的数据，排除pull requests
+func collectDigest(client *github.Client, owner, repo string, start, end time.Time, staleDays, top int) (*digest, error) {
+	d := &digest{Start: start, End: end, StaleDays: staleDays}
+	inWindow := func(t time.Time) bool {
+		return !t.Before(start) && t.Before(end)
+	}
+
+	// 周期内新建、关闭或有评论的issues的更新时间一定不早于起始日期
+	updated, err := listIssues(client, owner, repo, &github.IssueListByRepoOptions{State: "all", Since: start})
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range updated {
+		if issue.IsPullRequest() {
+			continue
+		}
+		if inWindow(issue.GetCreatedAt().Time) {
+			d.Opened = append(d.Opened, issue)
+		}
+		if issue.GetState() == "closed" && issue.ClosedAt != nil && inWindow(issue.GetClosedAt().Time) {
+			d.Closed = append(d.Closed, issue)
+		}
+		if issue.GetComments() == 0 {
+			continue
+		}
+		comments, err := fetchComments(client, owner, repo, issue.GetNumber())
+		if err != nil {
+			log.Printf("获取issue #%d 的评论失败: %v", issue.GetNumber(), err)
+			continue
+		}
+		count := 0
+		for _, comment := range comments {
+			if inWindow(comment.GetCreatedAt().Time) {
+				count++
+			}
+		}
+		if count > 0 {
+			d.Discussed = append(d.Discussed, digestDiscussion{Issue: issue, Comments: count})
+			d.CommentCount += count
+		}
+	}
+	sortIssuesByNumber(d.Opened)
+	sortIssuesByNumber(d.Closed)
+	sort.Slice(d.Discussed, func(i, j int) bool {
+		if d.Discussed[i].Comments != d.Discussed[j].Comments {
+			return d.Discussed[i].Comments > d.Discussed[j].Comments
+		}
+		return d.Discussed[i].Issue.GetNumber() < d.Discussed[j].Issue.GetNumber()
+	})
+	if top > 0 && len(d.Discussed) > top {
+		d.Discussed = d.Discussed[:top]
+	}
+
+	// 新增stale：在周期内达到staleDays天无更新的未关闭issues
+	open, err := listIssues(client, owner, repo, &github.IssueListByRepoOptions{State: "open"})
+	if err != nil {
+		return nil, err
+	}
+	staleWindow := time.Duration(staleDays) * durationDay
+	for _, issue := range open {
+		if issue.IsPullRequest() {
+			continue
+		}
+		d.OpenCount++
+		if inWindow(issue.GetUpdatedAt().Add(staleWindow)) {
+			d.NewStale = append(d.NewStale, issue)
+		}
+	}
+	sortIssuesByNumber(d.NewStale)
+
+	if d.LabelChanges, err = fetchLabelChanges(client, owner, repo, start, end); err != nil {
+		log.Printf("%v，报告中不包含标签变化", err)
+	}
+	return d, nil
+}
+
+func sortIssuesByNumber(issues []*github.Issue) {
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].GetNumber() < issues[j].GetNumber()
+	})
+}
+
+// 获取周期内issues的标签添加和移除事件，按变化次数排序
+func fetchLabelChanges(client *github.Client, owner, repo string, start, end time.Time) ([]*digestLabelChange, error) {
+	ctx := context.Background()
+	byName := make(map[string]*digestLabelChange)
+	changes := []*digestLabelChange{}
+
+	// 事件大致按时间从新到旧返回，但不保证严格有序，整页事件都早于起始日期时才停止翻页
+	opts := &github.ListOptions{PerPage: 100}
+	for {
+		events, resp, err := client.Issues.ListRepositoryEvents(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("获取issue事件失败: %w", err)
+		}
+
+		older := 0
+		for _, event := range events {
+			createdAt := event.GetCreatedAt().Time
+			if createdAt.Before(start) {
+				older++
+				continue
+			}
+			if !createdAt.Before(end) || event.Issue == nil || event.Issue.IsPullRequest() {
+				continue
+			}
+			if event.GetEvent() != "labeled" && event.GetEvent() != "unlabeled" {
+				continue
+			}
+			name := event.GetLabel().GetName()
+			change := byName[name]
+			if change == nil {
+				change = &digestLabelChange{Name: name}
+				byName[name] = change
+				changes = append(changes, change)
+			}
+			if event.GetEvent() == "labeled" {
+				change.Added = appendUniqueInt(change.Added, event.GetIssue().GetNumber())
+			} else {
+				change.Removed = appendUniqueInt(change.Removed, event.GetIssue().GetNumber())
+			}
+		}
+
+		if (len(events) > 0 && older == len(events)) || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		ci := len(changes[i].Added) + len(changes[i].Removed)
+		cj := len(changes[j].Added) + len(changes[j].Removed)
+		if ci != cj {
+			return ci > cj
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+func appendUniqueInt(list []int, n int) []int {
+	for _, v := range list {
+		if v == n {
+			return list
+		}
+	}
+	return append(list, n)
+}
+
+// issue编号列表，如 #1, #5
+func formatIssueNumbers(numbers []int) string {
+	sorted := append([]int(nil), numbers...)
+	sort.Ints(sorted)
+	refs := make([]string, len(sorted))
+	for i, n := range sorted {
+		refs[i] = fmt.Sprintf("#%d", n)
+	}
+	return strings.Join(refs, ", ")
+}
+
+// 生成Markdown格式的周期报告
+func formatDigest(d *digest) string {
+	last := d.End.AddDate(0, 0, -1)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Issues周期报告: %s（%s 至 %s）\n\n", d.Title, d.Start.Format("2006-01-02"), last.Format("2006-01-02")))
+
+	sb.WriteString("## 概览\n\n")
+	sb.WriteString("| 新建 | 关闭 | 评论 | 新增stale | 当前未关闭 |\n")
+	sb.WriteString("|------|------|------|-----------|------------|\n")
+	sb.WriteString(fmt.Sprintf("| %d | %d | %d | %d | %d |\n\n", len(d.Opened), len(d.Closed), d.CommentCount, len(d.NewStale), d.OpenCount))
+
+	sb.WriteString(fmt.Sprintf("## 新建的Issues (%d)\n\n", len(d.Opened)))
+	if len(d.Opened) == 0 {
+		sb.WriteString("无\n")
+	}
+	for _, issue := range d.Opened {
+		sb.WriteString(fmt.Sprintf("- [#%d](%s) %s（@%s", issue.GetNumber(), issue.GetHTMLURL(), issue.GetTitle(), issue.GetUser().GetLogin()))
+		if labels := issueLabelsString(issue); labels != "" {
+			sb.WriteString("，标签: " + labels)
+		}
+		if issue.GetState() == "closed" {
+			sb.WriteString("，已关闭")
+		}
+		sb.WriteString("）\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf("## 关闭的Issues (%d)\n\n", len(d.Closed)))
+	if len(d.Closed) == 0 {
+		sb.WriteString("无\n")
+	}
+	for _, issue := range d.Closed {
+		days := issue.GetClosedAt().Sub(issue.GetCreatedAt().Time).Hours() / 24
+		sb.WriteString(fmt.Sprintf("- [#%d](%s) %s（耗时 %.1f 天", issue.GetNumber(), issue.GetHTMLURL(), issue.GetTitle(), days))
+		if issue.GetStateReason() == "not_planned" {
+			sb.WriteString("，不计划处理")
+		}
+		sb.WriteString("）\n")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## 讨论最多的Issues\n\n")
+	if len(d.Discussed) == 0 {
+		sb.WriteString("无\n\n")
+	} else {
+		sb.WriteString("| Issue | 标题 | 本期评论 | 总评论 | 状态 |\n")
+		sb.WriteString("|-------|------|----------|--------|------|\n")
+		for _, item := range d.Discussed {
+			sb.WriteString(fmt.Sprintf("| [#%d](%s) | %s | %d | %d | %s |\n",
+				item.Issue.GetNumber(), item.Issue.GetHTMLURL(), strings.ReplaceAll(item.Issue.GetTitle(), "|", "\\|"),
+				item.Comments, item.Issue.GetComments(), item.Issue.GetState()))
+		}
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString(fmt.Sprintf("## 新增Stale Issues (%d)\n\n", len(d.NewStale)))
+	sb.WriteString(fmt.Sprintf("本期达到 %d 天无更新的未关闭issues。\n\n", d.StaleDays))
+	for _, issue := range d.NewStale {
+		sb.WriteString(fmt.Sprintf("- [#%d](%s) %s（最后更新于 %s）\n", issue.GetNumber(), issue.GetHTMLURL(), issue.GetTitle(),
+			issue.GetUpdatedAt().Format("2006-01-02")))
+	}
+	if len(d.NewStale) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("## 标签变化\n\n")
+	switch {
+	case d.LabelChanges == nil:
+		sb.WriteString("获取issue事件失败，未统计标签变化。\n")
+	case len(d.LabelChanges) == 0:
+		sb.WriteString("无\n")
+	default:
+		sb.WriteString("| 标签 | 添加 | 移除 |\n")
+		sb.WriteString("|------|------|------|\n")
+		for _, change := range d.LabelChanges {
+			added, removed := "-", "-"
+			if len(change.Added) > 0 {
+				added = fmt.Sprintf("%d（%s）", len(change.Added), formatIssueNumbers(change.Added))
+			}
+			if len(change.Removed) > 0 {
+				removed = fmt.Sprintf("%d（%s）", len(change.Removed), formatIssueNumbers(change.Removed))
+			}
+			sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n", change.Name, added, removed))
+		}
+	}
+	return sb.String()
+}
+
+// 使用AI根据周期报告撰写概述，outputDir为AI缓存所在的导出目录
+func generateDigestNarrative(cfg *Config, outputDir string, d *digest, report string) (string, error) {
+	client, err := newAIClient(cfg, outputDir)
+	if err != nil {
+		return "", err
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(fmt.Sprintf("以下是仓库 %s 在 %s 至 %s 期间的issues周期报告。", d.Title,
+		d.Start.Format("2006-01-02"), d.End.AddDate(0, 0, -1).Format("2006-01-02")))
+	prompt.WriteString("请以维护者分诊会议记录的口吻撰写一段Markdown格式的概述：总结本期的主要变化和趋势，指出需要优先处理的issues和风险，")
+	prompt.WriteString("并给出3条以内的行动建议。引用issue时注明编号（如 #12），不要编造报告中没有的内容，不要输出标题。\n\n")
+	prompt.WriteString(truncateToTokens(report, digestPromptMaxTokens))
+
+	fmt.Println("正在使用AI撰写概述...")
+	narrative, err := generateText(context.Background(), client, prompt.String())
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(narrative), nil
+}
+
+// 将AI概述插入到报告标题之后
+func insertDigestNarrative(report, narrative string) string {
+	title, rest, _ := strings.Cut(report, "\n\n")
+	return title + "\n\n## AI概述\n\n" + narrative + "\n\n" + rest
+}
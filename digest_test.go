@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+type testIssueEvent struct {
+	Event     string `json:"event"`
+	CreatedAt string `json:"created_at"`
+	Label     struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	Issue struct {
+		Number int `json:"number"`
+	} `json:"issue"`
+}
+
+func newTestIssueEvent(event, label string, number int, createdAt string) testIssueEvent {
+	e := testIssueEvent{Event: event, CreatedAt: createdAt}
+	e.Label.Name = label
+	e.Issue.Number = number
+	return e
+}
+
+func TestFetchLabelChangesWithUnorderedEvents(t *testing.T) {
+	// 事件大致从新到旧，但每页中混有早于起始日期的事件
+	pages := [][]testIssueEvent{
+		{
+			newTestIssueEvent("labeled", "bug", 1, "2024-03-10T00:00:00Z"),
+			newTestIssueEvent("labeled", "bug", 9, "2024-02-01T00:00:00Z"),
+			newTestIssueEvent("unlabeled", "triage", 2, "2024-03-09T00:00:00Z"),
+		},
+		{
+			newTestIssueEvent("labeled", "docs", 8, "2024-02-20T00:00:00Z"),
+			newTestIssueEvent("labeled", "bug", 3, "2024-03-02T00:00:00Z"),
+			newTestIssueEvent("labeled", "bug", 10, "2024-03-20T00:00:00Z"),
+		},
+		{
+			newTestIssueEvent("labeled", "bug", 7, "2024-02-10T00:00:00Z"),
+			newTestIssueEvent("labeled", "docs", 6, "2024-01-10T00:00:00Z"),
+		},
+		{
+			newTestIssueEvent("labeled", "bug", 5, "2024-03-05T00:00:00Z"),
+		},
+	}
+
+	requested := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		requested = max(requested, page)
+		if page < len(pages) {
+			next := *r.URL
+			q := next.Query()
+			q.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = q.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.String()))
+		}
+		json.NewEncoder(w).Encode(pages[page-1])
+	}))
+	defer server.Close()
+
+	client := github.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL + "/")
+
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	changes, err := fetchLabelChanges(client, "owner", "repo", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*digestLabelChange{
+		{Name: "bug", Added: []int{1, 3}},
+		{Name: "triage", Removed: []int{2}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("标签变化 = %+v，期望 %+v", changes, want)
+	}
+	// 第3页全部早于起始日期，不再请求第4页
+	if requested != 3 {
+		t.Errorf("请求到第 %d 页，期望在第3页停止", requested)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v57/github"
+)
+
+const (
+	// 未配置时生成讨论摘要的评论数阈值
+	defaultDiscussionMinComments = 10
+
+	// 评论内容在提示词中的token上限
+	discussionMaxTokens = 6000
+)
+
+// 评论讨论的AI摘要
+type discussionSummary struct {
+	Positions     []string `json:"positions"`
+	Decisions     []string `json:"decisions"`
+	OpenQuestions []string `json:"open_questions"`
+
+	// 模型没有返回JSON时保留原始内容
+	Text string `json:"-"`
+}
+
+// 为评论较多的issue生成讨论摘要
+type discussionSummarizer struct {
+	llm         *aiClient
+	minComments int
+}
+
+// 创建讨论摘要生成器，评论数超过minComments的issue才会生成摘要
+func newDiscussionSummarizer(cfg *Config, outputDir string) (*discussionSummarizer, error) {
+	llm, err := newAIClient(cfg, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	minComments := cfg.DiscussionMinComments
+	if minComments <= 0 {
+		minComments = defaultDiscussionMinComments
+	}
+	return &discussionSummarizer{llm: llm, minComments: minComments}, nil
+}
+
+// 总结issue的评论讨论，评论数未超过阈值时返回nil
+func (s *discussionSummarizer) summarize(issue *github.Issue, comments []*github.IssueComment) (*discussionSummary, error) {
+	if len(comments) <= s.minComments {
+		return nil, nil
+	}
+
+	completion, err := generateText(context.Background(), s.llm, buildDiscussionPrompt(issue, comments))
+	if err != nil {
+		return nil, err
+	}
+	return parseDiscussionSummary(completion), nil
+}
+
+// 构建讨论摘要的提示词
+func buildDiscussionPrompt(issue *github.Issue, comments []*github.IssueComment) string {
+	var sb strings.Builder
+	sb.WriteString("下面是一个GitHub issue下的评论讨论，请总结讨论中各方的观点、已经达成的决定和仍未解决的问题。")
+	sb.WriteString("只返回一个JSON对象，不要包含其他内容。JSON格式如下：\n")
+	sb.WriteString(`{"positions": ["@用户: 观点"], "decisions": ["已达成的决定"], "open_questions": ["未解决的问题"]}`)
+	sb.WriteString("\n\n")
+
+	sb.WriteString(fmt.Sprintf("标题: %s\n", issue.GetTitle()))
+	sb.WriteString(fmt.Sprintf("状态: %s\n", issue.GetState()))
+	sb.WriteString("\n描述:\n")
+	sb.WriteString(truncateToTokens(issue.GetBody(), issueAnalysisMaxTokens))
+
+	var thread strings.Builder
+	for _, comment := range comments {
+		thread.WriteString(fmt.Sprintf("@%s（%s）: %s\n\n",
+			comment.GetUser().GetLogin(),
+			comment.GetCreatedAt().Format("2006-01-02"),
+			comment.GetBody()))
+	}
+	sb.WriteString(fmt.Sprintf("\n\n评论（共%d条）:\n", len(comments)))
+	sb.WriteString(truncateToTokens(thread.String(), discussionMaxTokens))
+	return sb.String()
+}
+
+// 从模型返回内容中解析讨论摘要，不是JSON时保留原始内容
+func parseDiscussionSummary(completion string) *discussionSummary {
+	start := strings.Index(completion, "{")
+	end := strings.LastIndex(completion, "}")
+	if start >= 0 && end > start {
+		var summary discussionSummary
+		if err := json.Unmarshal([]byte(completion[start:end+1]), &summary); err == nil {
+			return &summary
+		}
+	}
+	return &discussionSummary{Text: strings.TrimSpace(completion)}
+}
+
+// 生成写入issue文件顶部的讨论摘要部分
+func formatDiscussionSummary(summary *discussionSummary) string {
+	var sb strings.Builder
+	sb.WriteString("## 讨论摘要\n\n")
+	sb.WriteString("*由AI根据评论自动生成*\n\n")
+
+	if summary.Text != "" {
+		sb.WriteString(summary.Text)
+		sb.WriteString("\n\n")
+		return sb.String()
+	}
+
+	sections := []struct {
+		title string
+		items []string
+	}{
+		{"各方观点", summary.Positions},
+		{"已达成的决定", summary.Decisions},
+		{"未解决的问题", summary.OpenQuestions},
+	}
+	for _, section := range sections {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", section.title))
+		if len(section.items) == 0 {
+			sb.WriteString("无\n\n")
+			continue
+		}
+		for _, item := range section.items {
+			sb.WriteString(fmt.Sprintf("- %s\n", item))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
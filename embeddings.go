@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tmc/langchaingo/embeddings"
+)
+
+// 向量存储文件名，位于导出目录下
+const embeddingStoreFile = "embeddings.json"
+
+// 重复检测报告文件名，位于导出目录下
+const duplicatesReportFile = "duplicates.md"
+
+// 未配置相似度阈值时使用的默认值
+const defaultSimilarityThreshold = 0.85
+
+// 单个issue参与向量化的最大token数，避免超出模型输入限制
+const maxEmbeddingTokens = 8000
+
+// 每次请求向量化的issues数量，每批完成后保存向量文件，中断后已完成的批次无需重新生成
+const embeddingBatchSize = 16
+
+// 本地向量存储
+type embeddingStore struct {
+	Model   string                   `json:"model"`
+	Vectors map[int]*embeddingRecord `json:"vectors"`
+}
+
+// 单个issue的向量，Hash用于判断issue内容是否有变化
+type embeddingRecord struct {
+	Hash   string    `json:"hash"`
+	Vector []float32 `json:"vector"`
+}
+
+// 相似度检索结果
+type similarIssue struct {
+	Issue *exportedIssue
+	Score float64
+}
+
+// similar子命令：查找与指定issue编号或文本语义相似的issues
+func runSimilar(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("similar", flag.ExitOnError)
+	dir := fs.String("dir", cfg.OutputDir, "已导出issues的目录")
+	threshold := fs.Float64("threshold", similarityThresholdOrDefault(cfg.SimilarityThreshold), "相似度阈值(0-1)")
+	limit := fs.Int("limit", 10, "最多显示的结果数")
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file similar [选项] <issue编号|文本>")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("未指定已导出issues的目录，请使用 -dir 参数")
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("未提供issue编号或文本")
+	}
+
+	ctx := context.Background()
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+	issues, store, err := syncEmbeddings(ctx, embedder, cfg.EmbeddingModel, *dir)
+	if err != nil {
+		return err
+	}
+
+	// 参数是已导出的issue编号时使用该issue的向量，否则将参数作为文本查询
+	input := strings.Join(fs.Args(), " ")
+	exclude := 0
+	var vector []float32
+	if number, err := strconv.Atoi(strings.TrimPrefix(input, "#")); err == nil && store.Vectors[number] != nil {
+		vector = store.Vectors[number].Vector
+		exclude = number
+	} else {
+		vector, err = embedder.EmbedQuery(ctx, input)
+		if err != nil {
+			return fmt.Errorf("向量化查询文本失败: %w", err)
+		}
+	}
+
+	results := rankSimilarIssues(issues, store, vector, exclude, *threshold)
+	if len(results) == 0 {
+		fmt.Printf("没有找到相似度不低于 %.2f 的issues\n", *threshold)
+		return nil
+	}
+	if len(results) > *limit {
+		results = results[:*limit]
+	}
+
+	for _, result := range results {
+		fmt.Printf("%.3f  #%d [%s] %s\n", result.Score, result.Issue.Number, result.Issue.State, result.Issue.Title)
+		fmt.Printf("       %s\n", result.Issue.Path)
+	}
+	return nil
+}
+
+// duplicates子命令：按相似度阈值将issues聚类，报告可能重复的issues
+func runDuplicates(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("duplicates", flag.ExitOnError)
+	dir := fs.String("dir", cfg.OutputDir, "已导出issues的目录")
+	threshold := fs.Float64("threshold", similarityThresholdOrDefault(cfg.SimilarityThreshold), "相似度阈值(0-1)")
+	fs.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("未指定已导出issues的目录，请使用 -dir 参数")
+	}
+
+	embedder, err := newEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+	issues, store, err := syncEmbeddings(context.Background(), embedder, cfg.EmbeddingModel, *dir)
+	if err != nil {
+		return err
+	}
+
+	clusters := findDuplicateClusters(issues, store, *threshold)
+	if len(clusters) == 0 {
+		fmt.Printf("没有发现相似度不低于 %.2f 的重复issues\n", *threshold)
+		return nil
+	}
+
+	report := generateDuplicatesReport(clusters, *threshold)
+	fmt.Print(report)
+
+	reportPath := filepath.Join(*dir, duplicatesReportFile)
+	if err := os.WriteFile(reportPath, []byte(report), 0644); err != nil {
+		return fmt.Errorf("保存重复检测报告失败: %w", err)
+	}
+	fmt.Printf("重复检测报告已保存到: %s\n", reportPath)
+	return nil
+}
+
+func similarityThresholdOrDefault(threshold float64) float64 {
+	if threshold <= 0 {
+		return defaultSimilarityThreshold
+	}
+	return threshold
+}
+
+// 读取已导出的issues，并为新增或内容有变化的issues生成向量
+func syncEmbeddings(ctx context.Context, embedder embeddings.Embedder, model, dir string) ([]*exportedIssue, *embeddingStore, error) {
+	issues, err := loadExportedIssues(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	storePath := filepath.Join(dir, embeddingStoreFile)
+	store, err := loadEmbeddingStore(storePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 更换了模型时，原有向量不再可比，全部重新生成
+	if store.Model != model {
+		store = &embeddingStore{Model: model, Vectors: make(map[int]*embeddingRecord)}
+	}
+
+	var pending []*exportedIssue
+	var texts, hashes []string
+	existing := make(map[int]bool, len(issues))
+	for _, issue := range issues {
+		existing[issue.Number] = true
+
+		text := embeddingText(issue)
+		sum := sha256.Sum256([]byte(text))
+		hash := hex.EncodeToString(sum[:])
+		if record := store.Vectors[issue.Number]; record != nil && record.Hash == hash {
+			continue
+		}
+		pending = append(pending, issue)
+		texts = append(texts, text)
+		hashes = append(hashes, hash)
+	}
+
+	// 删除已不存在的issues的向量
+	for number := range store.Vectors {
+		if !existing[number] {
+			delete(store.Vectors, number)
+		}
+	}
+
+	if len(pending) == 0 {
+		if err := saveEmbeddingStore(storePath, store); err != nil {
+			return nil, nil, err
+		}
+		return issues, store, nil
+	}
+
+	fmt.Printf("正在为 %d 个issues生成向量...\n", len(pending))
+	for start := 0; start < len(pending); start += embeddingBatchSize {
+		end := min(start+embeddingBatchSize, len(pending))
+		vectors, err := embedder.EmbedDocuments(ctx, texts[start:end])
+		if err != nil {
+			return nil, nil, fmt.Errorf("生成向量失败: %w", err)
+		}
+		if len(vectors) != end-start {
+			return nil, nil, fmt.Errorf("生成向量失败: 期望 %d 个向量，实际返回 %d 个", end-start, len(vectors))
+		}
+		for i, issue := range pending[start:end] {
+			store.Vectors[issue.Number] = &embeddingRecord{Hash: hashes[start+i], Vector: vectors[i]}
+		}
+		if err := saveEmbeddingStore(storePath, store); err != nil {
+			return nil, nil, err
+		}
+	}
+	return issues, store, nil
+}
+
+// 参与向量化的issue文本：标题、描述和评论
+func embeddingText(issue *exportedIssue) string {
+	var sb strings.Builder
+	sb.WriteString(issue.Title)
+	sb.WriteString("\n\n")
+	sb.WriteString(issue.Body)
+	for _, comment := range issue.Comments {
+		sb.WriteString("\n\n")
+		sb.WriteString(comment.Body)
+	}
+
+	return truncateToTokens(sb.String(), maxEmbeddingTokens)
+}
+
+func loadEmbeddingStore(path string) (*embeddingStore, error) {
+	store := &embeddingStore{Vectors: make(map[int]*embeddingRecord)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取向量文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("解析向量文件失败: %w", err)
+	}
+	if store.Vectors == nil {
+		store.Vectors = make(map[int]*embeddingRecord)
+	}
+	return store, nil
+}
+
+func saveEmbeddingStore(path string, store *embeddingStore) error {
+	data, err := json.Marshal(store)
+	if err != nil {
+		return fmt.Errorf("序列化向量失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存向量文件失败: %w", err)
+	}
+	return nil
+}
+
+// 按与指定向量的相似度从高到低排序，过滤掉低于阈值的issues
+func rankSimilarIssues(issues []*exportedIssue, store *embeddingStore, vector []float32, exclude int, threshold float64) []similarIssue {
+	var results []similarIssue
+	for _, issue := range issues {
+		record := store.Vectors[issue.Number]
+		if issue.Number == exclude || record == nil {
+			continue
+		}
+		if score := cosineSimilarity(vector, record.Vector); score >= threshold {
+			results = append(results, similarIssue{Issue: issue, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// 将相似度不低于阈值的issues两两连接，返回包含多个issues的连通分量
+func findDuplicateClusters(issues []*exportedIssue, store *embeddingStore, threshold float64) [][]similarIssue {
+	parent := make([]int, len(issues))
+	best := make([]float64, len(issues))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < len(issues); i++ {
+		a := store.Vectors[issues[i].Number]
+		if a == nil {
+			continue
+		}
+		for j := i + 1; j < len(issues); j++ {
+			b := store.Vectors[issues[j].Number]
+			if b == nil {
+				continue
+			}
+			score := cosineSimilarity(a.Vector, b.Vector)
+			if score < threshold {
+				continue
+			}
+			best[i] = math.Max(best[i], score)
+			best[j] = math.Max(best[j], score)
+			parent[find(i)] = find(j)
+		}
+	}
+
+	groups := make(map[int][]similarIssue)
+	for i, issue := range issues {
+		root := find(i)
+		groups[root] = append(groups[root], similarIssue{Issue: issue, Score: best[i]})
+	}
+
+	var clusters [][]similarIssue
+	for _, group := range groups {
+		if len(group) > 1 {
+			clusters = append(clusters, group)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0].Issue.Number < clusters[j][0].Issue.Number
+	})
+	return clusters
+}
+
+// 生成重复检测报告
+func generateDuplicatesReport(clusters [][]similarIssue, threshold float64) string {
+	var sb strings.Builder
+	sb.WriteString("# 可能重复的Issues\n\n")
+	sb.WriteString(fmt.Sprintf("相似度阈值: %.2f，共发现 %d 组\n\n", threshold, len(clusters)))
+
+	for i, cluster := range clusters {
+		sb.WriteString(fmt.Sprintf("## 第 %d 组（%d 个issues）\n\n", i+1, len(cluster)))
+		for _, item := range cluster {
+			sb.WriteString(fmt.Sprintf("- #%d [%s] %s（最高相似度 %.3f）: %s\n",
+				item.Issue.Number, item.Issue.State, item.Issue.Title, item.Score, item.Issue.Path))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// 计算两个向量的余弦相似度
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 从已导出的Markdown文件中解析出的issue
+type exportedIssue struct {
+	Number    int
+	Title     string
+	State     string
+	Author    string
+	CreatedAt time.Time
+	Labels    []string
+	Assignees []string
+	Milestone string
+	URL       string
+	Body      string
+	Comments  []exportedComment
+	Path      string
+}
+
+// 从已导出的Markdown文件中解析出的评论
+type exportedComment struct {
+	Author    string
+	CreatedAt time.Time
+	Body      string
+}
+
+var (
+	exportedTitleRegex   = regexp.MustCompile(`(?m)^# Issue #(\d+): (.*)$`)
+	exportedInfoRegex    = regexp.MustCompile(`(?m)^- \*\*(.+?)\*\*: (.*)$`)
+	exportedCommentRegex = regexp.MustCompile(`(?m)^### @(\S+) 评论于 (\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})$`)
+)
+
+const (
+	exportedBodyMarker     = "\n## 描述\n\n"
+	exportedCommentsMarker = "\n---\n\n## 评论\n\n"
+	exportedInfoMarker     = "\n## 基本信息\n\n"
+	exportedTimeLayout     = "2006-01-02 15:04:05"
+)
+
+// 列出目录下所有已导出的issue文件
+func listExportedIssueFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "issue_*.md"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// 读取目录下所有已导出的issues
+func loadExportedIssues(dir string) ([]*exportedIssue, error) {
+	files, err := listExportedIssueFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("查找issue文件失败: %w", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("目录 %s 中没有找到已导出的issue文件", dir)
+	}
+
+	issues := make([]*exportedIssue, 0, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件 %s 失败: %w", file, err)
+		}
+
+		issue, err := parseExportedIssue(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("解析文件 %s 失败: %w", file, err)
+		}
+		issue.Path = file
+		issues = append(issues, issue)
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Number < issues[j].Number
+	})
+	return issues, nil
+}
+
+// 解析由generateMarkdownContent生成的Markdown内容
+func parseExportedIssue(content string) (*exportedIssue, error) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	matches := exportedTitleRegex.FindStringSubmatch(content)
+	if matches == nil {
+		return nil, fmt.Errorf("未找到issue标题")
+	}
+	number, err := strconv.Atoi(matches[1])
+	if err != nil {
+		return nil, fmt.Errorf("无效的issue编号: %w", err)
+	}
+	issue := &exportedIssue{
+		Number: number,
+		Title:  strings.TrimSpace(matches[2]),
+	}
+
+	// 按描述和评论的分隔标记切分内容
+	info, body, comments := content, "", ""
+	if idx := strings.Index(info, exportedCommentsMarker); idx >= 0 {
+		info, comments = info[:idx], info[idx+len(exportedCommentsMarker):]
+	}
+	if idx := strings.Index(info, exportedBodyMarker); idx >= 0 {
+		info, body = info[:idx], info[idx+len(exportedBodyMarker):]
+	}
+	issue.Body = strings.TrimSpace(body)
+
+	// 基本信息只在对应章节中查找，避免匹配到讨论摘要等AI生成的内容
+	if idx := strings.Index(info, exportedInfoMarker); idx >= 0 {
+		info = info[idx+len(exportedInfoMarker):]
+		if end := strings.Index(info, "\n## "); end >= 0 {
+			info = info[:end]
+		}
+	}
+	for _, m := range exportedInfoRegex.FindAllStringSubmatch(info, -1) {
+		value := strings.TrimSpace(m[2])
+		switch m[1] {
+		case "状态":
+			issue.State = value
+		case "创建者":
+			issue.Author = strings.TrimPrefix(value, "@")
+		case "创建时间":
+			issue.CreatedAt, _ = time.Parse(exportedTimeLayout, value)
+		case "标签":
+			for _, label := range strings.Split(value, ", ") {
+				issue.Labels = append(issue.Labels, strings.Trim(label, "`"))
+			}
+		case "指派给":
+			for _, assignee := range strings.Split(value, ", ") {
+				issue.Assignees = append(issue.Assignees, strings.TrimPrefix(assignee, "@"))
+			}
+		case "里程碑":
+			issue.Milestone = value
+		case "链接":
+			issue.URL = value
+		}
+	}
+
+	// 评论
+	headers := exportedCommentRegex.FindAllStringSubmatchIndex(comments, -1)
+	for i, h := range headers {
+		end := len(comments)
+		if i+1 < len(headers) {
+			end = headers[i+1][0]
+		}
+		text := strings.TrimSpace(comments[h[1]:end])
+		text = strings.TrimSpace(strings.TrimSuffix(text, "---"))
+
+		createdAt, _ := time.Parse(exportedTimeLayout, comments[h[4]:h[5]])
+		issue.Comments = append(issue.Comments, exportedComment{
+			Author:    comments[h[2]:h[3]],
+			CreatedAt: createdAt,
+			Body:      text,
+		})
+	}
+
+	return issue, nil
+}
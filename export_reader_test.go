@@ -0,0 +1,125 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestParseExportedIssueRoundTrip(t *testing.T) {
+	created := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	commented := time.Date(2024, 3, 5, 8, 9, 10, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		issue    *github.Issue
+		comments []*github.IssueComment
+		want     *exportedIssue
+	}{
+		{
+			name: "只有基本信息",
+			issue: &github.Issue{
+				Number:    github.Int(1),
+				Title:     github.String("Crash on start"),
+				State:     github.String("open"),
+				User:      &github.User{Login: github.String("alice")},
+				CreatedAt: &github.Timestamp{Time: created},
+				HTMLURL:   github.String("https://github.com/o/r/issues/1"),
+			},
+			want: &exportedIssue{
+				Number:    1,
+				Title:     "Crash on start",
+				State:     "open",
+				Author:    "alice",
+				CreatedAt: created,
+				URL:       "https://github.com/o/r/issues/1",
+			},
+		},
+		{
+			name: "标签、指派人、里程碑和描述",
+			issue: &github.Issue{
+				Number:    github.Int(42),
+				Title:     github.String("Support: dark mode"),
+				State:     github.String("closed"),
+				User:      &github.User{Login: github.String("bob")},
+				CreatedAt: &github.Timestamp{Time: created},
+				ClosedAt:  &github.Timestamp{Time: commented},
+				Labels:    []*github.Label{{Name: github.String("enhancement")}, {Name: github.String("ui")}},
+				Assignees: []*github.User{{Login: github.String("carol")}, {Login: github.String("dave")}},
+				Milestone: &github.Milestone{Title: github.String("v1.2")},
+				HTMLURL:   github.String("https://github.com/o/r/issues/42"),
+				Body:      github.String("Please add dark mode.\n\n## Details\n\n- **状态**: not a field"),
+			},
+			want: &exportedIssue{
+				Number:    42,
+				Title:     "Support: dark mode",
+				State:     "closed",
+				Author:    "bob",
+				CreatedAt: created,
+				Labels:    []string{"enhancement", "ui"},
+				Assignees: []string{"carol", "dave"},
+				Milestone: "v1.2",
+				URL:       "https://github.com/o/r/issues/42",
+				Body:      "Please add dark mode.\n\n## Details\n\n- **状态**: not a field",
+			},
+		},
+		{
+			name: "多条评论",
+			issue: &github.Issue{
+				Number:    github.Int(7),
+				Title:     github.String("Typo in docs"),
+				State:     github.String("open"),
+				User:      &github.User{Login: github.String("erin")},
+				CreatedAt: &github.Timestamp{Time: created},
+				HTMLURL:   github.String("https://github.com/o/r/issues/7"),
+				Body:      github.String("There is a typo."),
+			},
+			comments: []*github.IssueComment{
+				{
+					User:      &github.User{Login: github.String("frank")},
+					CreatedAt: &github.Timestamp{Time: commented},
+					Body:      github.String("Confirmed.\n\nSee line 3."),
+				},
+				{
+					User:      &github.User{Login: github.String("erin")},
+					CreatedAt: &github.Timestamp{Time: commented.Add(time.Hour)},
+					Body:      github.String("Thanks!"),
+				},
+			},
+			want: &exportedIssue{
+				Number:    7,
+				Title:     "Typo in docs",
+				State:     "open",
+				Author:    "erin",
+				CreatedAt: created,
+				URL:       "https://github.com/o/r/issues/7",
+				Body:      "There is a typo.",
+				Comments: []exportedComment{
+					{Author: "frank", CreatedAt: commented, Body: "Confirmed.\n\nSee line 3."},
+					{Author: "erin", CreatedAt: commented.Add(time.Hour), Body: "Thanks!"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := generateMarkdownContent(tt.issue, tt.comments, nil, nil)
+			got, err := parseExportedIssue(content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExportedIssue() = %+v\n期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExportedIssueWithoutTitle(t *testing.T) {
+	if _, err := parseExportedIssue("## 基本信息\n\n- **状态**: open\n"); err == nil {
+		t.Fatal("缺少标题时应返回错误")
+	}
+}
@@ -51,6 +51,21 @@ func getRepoFromGitConfig() (owner, repo string, err error) {
 	return "", "", fmt.Errorf("在.git/config中未找到origin远程仓库")
 }
 
+// 解析命令行中的仓库参数，"."表示从当前目录的.git/config读取仓库信息
+func resolveRepo(repoArg string) (owner, repo string, err error) {
+	if repoArg == "." {
+		if owner, repo, err = getRepoFromGitConfig(); err != nil {
+			return "", "", fmt.Errorf("无法从.git/config获取仓库信息: %w", err)
+		}
+		return owner, repo, nil
+	}
+
+	if owner, repo, err = parseRepoURL(repoArg); err != nil {
+		return "", "", fmt.Errorf("无法解析仓库地址: %w", err)
+	}
+	return owner, repo, nil
+}
+
 // 解析各种格式的仓库URL
 func parseRepoURL(repoURL string) (owner, repo string, err error) {
 	// 去除前后空格
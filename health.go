@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+const (
+	// 健康报告的文件名，位于输出目录下
+	healthReportFile = "health.md"
+
+	// 健康指标的统计窗口：截至生成时间的滚动30天，不按自然月对齐，与前一个30天比较得到环比变化
+	healthWindow = 30 * durationDay
+
+	// 开关比趋势显示的月数
+	healthTrendMonths = 6
+
+	// 响应者的巴士因子：承担一半响应的最少人数
+	healthBusFactorShare = 0.5
+)
+
+// 一个统计窗口内的健康指标
+type healthMetrics struct {
+	Start, End time.Time
+
+	// 窗口内新建和关闭的数量，以及窗口结束时未关闭的数量
+	Opened, Closed, Open int
+
+	// 窗口内新建的issues中带有标签和指派人的数量
+	Labeled, Assigned int
+
+	// 窗口结束时超过staleDays天无活动的未关闭issues数量
+	Stale int
+
+	// 窗口内新建issues的首次响应时间和窗口内关闭issues的关闭耗时，单位为天
+	ResponseDays, CloseDays []float64
+
+	// 窗口内每个响应者的评论数，为nil表示没有下载评论
+	Responders map[string]int
+}
+
+// 健康报告中的一项指标，Score为0到1的得分，Available为false时不参与健康分计算
+type healthIndicator struct {
+	Name      string
+	Weight    float64
+	Value     float64
+	Unit      string
+	Score     float64
+	Available bool
+}
+
+// 生成健康报告：中位响应和关闭耗时、开关比趋势、标签和指派覆盖率、stale数量和响应者巴士因子
+// 报告保存为输出目录下的health.md，通过图表索引页链接
+func generateHealthReport(c *chartContext) error {
+	if len(c.issues) == 0 {
+		return fmt.Errorf("%w: 没有issues", errChartSkipped)
+	}
+	staleDays := c.cfg.StaleDays
+	if staleDays <= 0 {
+		staleDays = defaultStaleDays
+	}
+
+	now := time.Now()
+	current := computeHealthMetrics(c, now.Add(-healthWindow), now, staleDays)
+	previous := computeHealthMetrics(c, now.Add(-2*healthWindow), now.Add(-healthWindow), staleDays)
+	report := formatHealthReport(c, current, previous, staleDays, now)
+
+	path := filepath.Join(filepath.Dir(c.dir), healthReportFile)
+	if err := os.WriteFile(path, []byte(report), 0644); err != nil {
+		return fmt.Errorf("保存健康报告失败: %w", err)
+	}
+	return nil
+}
+
+// issue在指定时间是否未关闭
+func openAt(issue *github.Issue, t time.Time) bool {
+	if !issue.GetCreatedAt().Before(t) {
+		return false
+	}
+	return !(issue.GetState() == "closed" && issue.ClosedAt != nil && issue.GetClosedAt().Before(t))
+}
+
+// issue在指定时间之前的最后活动时间：创建、评论或更新，更新时间晚于t时无法使用
+func lastActivityBefore(issue *github.Issue, comments []*github.IssueComment, t time.Time) time.Time {
+	last := issue.GetCreatedAt().Time
+	if updated := issue.GetUpdatedAt().Time; updated.Before(t) && updated.After(last) {
+		last = updated
+	}
+	for _, comment := range comments {
+		if created := comment.GetCreatedAt().Time; created.Before(t) && created.After(last) {
+			last = created
+		}
+	}
+	return last
+}
+
+// 计算[start, end)窗口内的健康指标
+func computeHealthMetrics(c *chartContext, start, end time.Time, staleDays int) *healthMetrics {
+	m := &healthMetrics{Start: start, End: end}
+	inWindow := func(t time.Time) bool {
+		return !t.Before(start) && t.Before(end)
+	}
+	if c.comments != nil {
+		m.Responders = make(map[string]int)
+	}
+
+	staleBefore := end.Add(-time.Duration(staleDays) * durationDay)
+	for _, issue := range c.issues {
+		comments := c.comments[issue.GetNumber()]
+		if inWindow(issue.GetCreatedAt().Time) {
+			m.Opened++
+			if len(issue.Labels) > 0 {
+				m.Labeled++
+			}
+			if len(issue.Assignees) > 0 || issue.Assignee != nil {
+				m.Assigned++
+			}
+			if c.comments != nil {
+				if d, ok := firstResponseTime(issue, comments); ok {
+					m.ResponseDays = append(m.ResponseDays, d.Hours()/24)
+				}
+			}
+		}
+		if issue.GetState() == "closed" && issue.ClosedAt != nil && inWindow(issue.GetClosedAt().Time) {
+			m.Closed++
+			m.CloseDays = append(m.CloseDays, issue.GetClosedAt().Sub(issue.GetCreatedAt().Time).Hours()/24)
+		}
+		if openAt(issue, end) {
+			m.Open++
+			if lastActivityBefore(issue, comments, end).Before(staleBefore) {
+				m.Stale++
+			}
+		}
+
+		// 响应者为在别人的issue下评论的非机器人用户
+		for _, comment := range comments {
+			user := comment.GetUser()
+			if !inWindow(comment.GetCreatedAt().Time) || user.GetLogin() == "" || user.GetLogin() == issue.GetUser().GetLogin() || isBotUser(user) {
+				continue
+			}
+			m.Responders[user.GetLogin()]++
+		}
+	}
+	sort.Float64s(m.ResponseDays)
+	sort.Float64s(m.CloseDays)
+	return m
+}
+
+// 响应者的巴士因子：评论数最多的几个人合计承担一半以上的响应时的人数
+func (m *healthMetrics) busFactor() int {
+	total := 0
+	for _, count := range m.Responders {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	covered := 0
+	for i, item := range sortedCounts(m.Responders, 0) {
+		covered += item.Count
+		if float64(covered) >= float64(total)*healthBusFactorShare {
+			return i + 1
+		}
+	}
+	return len(m.Responders)
+}
+
+// 将值限制在0到1之间
+func clampScore(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// 计算各项指标和得分，权重之和为100
+func (m *healthMetrics) indicators() []healthIndicator {
+	var items []healthIndicator
+
+	// 中位首次响应时间，0天满分，14天及以上0分
+	response := healthIndicator{Name: "中位首次响应时间", Weight: 20, Unit: "天", Available: len(m.ResponseDays) > 0}
+	if response.Available {
+		response.Value = percentile(m.ResponseDays, 50)
+		response.Score = clampScore(1 - response.Value/14)
+	}
+	items = append(items, response)
+
+	// 中位关闭耗时，0天满分，90天及以上0分
+	closeTime := healthIndicator{Name: "中位关闭耗时", Weight: 15, Unit: "天", Available: len(m.CloseDays) > 0}
+	if closeTime.Available {
+		closeTime.Value = percentile(m.CloseDays, 50)
+		closeTime.Score = clampScore(1 - closeTime.Value/90)
+	}
+	items = append(items, closeTime)
+
+	// 关闭数与新建数之比，关闭不少于新建时满分
+	ratio := healthIndicator{Name: "关闭/新建比", Weight: 20, Available: m.Opened > 0 || m.Closed > 0}
+	if m.Opened > 0 {
+		ratio.Value = float64(m.Closed) / float64(m.Opened)
+		ratio.Score = clampScore(ratio.Value)
+	} else if ratio.Available {
+		ratio.Value = 1
+		ratio.Score = 1
+	}
+	items = append(items, ratio)
+
+	// 新建issues中带有标签和指派人的比例
+	labeled := healthIndicator{Name: "已打标签比例", Weight: 10, Unit: "%", Available: m.Opened > 0}
+	assigned := healthIndicator{Name: "已指派比例", Weight: 10, Unit: "%", Available: m.Opened > 0}
+	if m.Opened > 0 {
+		labeled.Value = float64(m.Labeled) * 100 / float64(m.Opened)
+		labeled.Score = labeled.Value / 100
+		assigned.Value = float64(m.Assigned) * 100 / float64(m.Opened)
+		assigned.Score = assigned.Value / 100
+	}
+	items = append(items, labeled, assigned)
+
+	// 未关闭issues中stale的比例
+	stale := healthIndicator{Name: "Stale issues", Weight: 15, Unit: "个", Value: float64(m.Stale), Available: true}
+	stale.Score = 1
+	if m.Open > 0 {
+		stale.Score = 1 - float64(m.Stale)/float64(m.Open)
+	}
+	items = append(items, stale)
+
+	// 响应者的巴士因子，3人及以上满分
+	bus := healthIndicator{Name: "响应者巴士因子", Weight: 10, Unit: "人", Available: len(m.Responders) > 0}
+	if bus.Available {
+		bus.Value = float64(m.busFactor())
+		bus.Score = clampScore(bus.Value / 3)
+	}
+	items = append(items, bus)
+
+	return items
+}
+
+// 按可用指标的权重计算0到100的健康分，没有可用指标时返回false
+func healthScore(items []healthIndicator) (float64, bool) {
+	var total, weights float64
+	for _, item := range items {
+		if item.Available {
+			total += item.Score * item.Weight
+			weights += item.Weight
+		}
+	}
+	if weights == 0 {
+		return 0, false
+	}
+	return total / weights * 100, true
+}
+
+func formatHealthValue(item healthIndicator) string {
+	if !item.Available {
+		return "-"
+	}
+	switch item.Unit {
+	case "个", "人":
+		return fmt.Sprintf("%.0f %s", item.Value, item.Unit)
+	case "%":
+		return fmt.Sprintf("%.0f%%", item.Value)
+	case "":
+		return fmt.Sprintf("%.2f", item.Value)
+	default:
+		return fmt.Sprintf("%.1f %s", item.Value, item.Unit)
+	}
+}
+
+func formatHealthDelta(current, previous healthIndicator) string {
+	if !current.Available || !previous.Available {
+		return "-"
+	}
+	delta := current.Value - previous.Value
+	switch current.Unit {
+	case "个", "人":
+		return fmt.Sprintf("%+.0f", delta)
+	case "%":
+		return fmt.Sprintf("%+.0f%%", delta)
+	case "":
+		return fmt.Sprintf("%+.2f", delta)
+	default:
+		return fmt.Sprintf("%+.1f %s", delta, current.Unit)
+	}
+}
+
+// 生成Markdown格式的健康报告
+func formatHealthReport(c *chartContext, current, previous *healthMetrics, staleDays int, now time.Time) string {
+	currentItems, previousItems := current.indicators(), previous.indicators()
+
+	var sb strings.Builder
+	sb.WriteString("# 仓库健康报告\n\n")
+	sb.WriteString(fmt.Sprintf("生成时间: %s，统计窗口为最近30天（%s 至 %s），与前30天比较\n\n",
+		now.Format("2006-01-02 15:04"), current.Start.Format("2006-01-02"), current.End.Format("2006-01-02")))
+
+	score, ok := healthScore(currentItems)
+	if !ok {
+		sb.WriteString("## 健康分: -\n\n")
+	} else if previousScore, ok := healthScore(previousItems); ok {
+		sb.WriteString(fmt.Sprintf("## 健康分: %.0f（环比 %+.0f）\n\n", score, score-previousScore))
+	} else {
+		sb.WriteString(fmt.Sprintf("## 健康分: %.0f\n\n", score))
+	}
+
+	sb.WriteString("| 指标 | 最近30天 | 前30天 | 变化 | 得分 | 权重 |\n")
+	sb.WriteString("|------|----------|--------|------|------|------|\n")
+	for i, item := range currentItems {
+		itemScore := "-"
+		if item.Available {
+			itemScore = fmt.Sprintf("%.0f", item.Score*100)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %.0f |\n",
+			item.Name, formatHealthValue(item), formatHealthValue(previousItems[i]),
+			formatHealthDelta(item, previousItems[i]), itemScore, item.Weight))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(fmt.Sprintf("最近30天新建 %d 个、关闭 %d 个，当前未关闭 %d 个，其中 %d 个超过 %d 天无活动。\n\n",
+		current.Opened, current.Closed, current.Open, current.Stale, staleDays))
+
+	// 每月开关比趋势
+	sb.WriteString("## 开关比趋势\n\n")
+	sb.WriteString("| 月份 | 新建 | 关闭 | 关闭/新建 | 月末未关闭 |\n")
+	sb.WriteString("|------|------|------|-----------|------------|\n")
+	month := periodStart(now, "month").AddDate(0, -(healthTrendMonths - 1), 0)
+	for i := 0; i < healthTrendMonths; i++ {
+		next := month.AddDate(0, 1, 0)
+		end := next
+		if end.After(now) {
+			end = now
+		}
+		opened, closed, open := 0, 0, 0
+		for _, issue := range c.issues {
+			if created := issue.GetCreatedAt().Time; !created.Before(month) && created.Before(next) {
+				opened++
+			}
+			if issue.GetState() == "closed" && issue.ClosedAt != nil {
+				if closedAt := issue.GetClosedAt().Time; !closedAt.Before(month) && closedAt.Before(next) {
+					closed++
+				}
+			}
+			if openAt(issue, end) {
+				open++
+			}
+		}
+		ratio := "-"
+		if opened > 0 {
+			ratio = fmt.Sprintf("%.2f", float64(closed)/float64(opened))
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %d | %d | %s | %d |\n", month.Format("2006-01"), opened, closed, ratio, open))
+		month = next
+	}
+	sb.WriteString("\n")
+
+	// 响应者
+	if current.Responders != nil {
+		sb.WriteString("## 响应者\n\n")
+		if len(current.Responders) == 0 {
+			sb.WriteString("最近30天没有其他用户回复issues。\n\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("最近30天共有 %d 位响应者，巴士因子为 %d（承担一半以上回复的最少人数）。\n\n",
+				len(current.Responders), current.busFactor()))
+			sb.WriteString("| 响应者 | 评论数 |\n")
+			sb.WriteString("|--------|--------|\n")
+			for _, item := range sortedCounts(current.Responders, c.topPeople) {
+				sb.WriteString(fmt.Sprintf("| @%s | %d |\n", item.Name, item.Count))
+			}
+			sb.WriteString("\n")
+		}
+	}
+
+	sb.WriteString("## 说明\n\n")
+	sb.WriteString("- 统计窗口为截至生成时间的滚动30天，不按自然月对齐；开关比趋势按自然月统计，当月截至生成时间\n")
+	sb.WriteString("- 健康分为各项指标得分按权重的加权平均（0-100），缺少数据的指标不参与计算\n")
+	sb.WriteString("- 首次响应时间和关闭耗时取窗口内新建或关闭的issues的中位数，分别在0天和14天、0天和90天之间线性计分\n")
+	sb.WriteString("- 已打标签和已指派比例按窗口内新建的issues的当前标签和指派人统计\n")
+	sb.WriteString("- 响应者为在他人issue下评论的非机器人用户，巴士因子3人及以上为满分\n")
+	if c.comments == nil {
+		sb.WriteString("- 未下载评论（使用 `-comment` 启用），首次响应时间和响应者巴士因子不可用，stale按更新时间判断\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const (
+	// 逐个分析结果的缓存文件名，位于输出目录下
+	issueAnalysisCacheFile = ".ai_issue_cache.json"
+
+	// 分诊结果CSV文件名，位于输出目录下
+	triageCSVFile = "triage.csv"
+
+	// 单个issue的描述和评论在提示词中的token上限
+	issueAnalysisMaxTokens = 3000
+)
+
+// 可选的issue分类
+var issueCategories = []string{"bug", "feature", "question", "docs"}
+
+// 单个issue的AI分析结果
+type issueAnalysis struct {
+	Category        string   `json:"category"`
+	Severity        string   `json:"severity"`
+	SuggestedLabels []string `json:"suggested_labels"`
+	Summary         string   `json:"summary"`
+	NextAction      string   `json:"next_action"`
+}
+
+// 缓存的分析结果，issue的更新时间、模型和提示词都不变时直接复用
+type issueAnalysisCacheEntry struct {
+	UpdatedAt  string         `json:"updated_at"`
+	Model      string         `json:"model"`
+	PromptHash string         `json:"prompt_hash"`
+	Analysis   *issueAnalysis `json:"analysis"`
+}
+
+// 逐个分析issues，并汇总生成分诊CSV
+type issueAnalyzer struct {
+	llm       llms.Model
+	model     string
+	cachePath string
+	cache     map[int]*issueAnalysisCacheEntry
+	results   map[int]*issueAnalysis
+	issues    map[int]*github.Issue
+}
+
+// 创建逐个分析issues的分析器，加载输出目录下的缓存
+func newIssueAnalyzer(cfg *Config, outputDir string) (*issueAnalyzer, error) {
+	llm, err := newAIClient(cfg, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &issueAnalyzer{
+		llm:       llm,
+		model:     aiProvider(cfg) + "/" + cfg.AIModel,
+		cachePath: filepath.Join(outputDir, issueAnalysisCacheFile),
+		cache:     make(map[int]*issueAnalysisCacheEntry),
+		results:   make(map[int]*issueAnalysis),
+		issues:    make(map[int]*github.Issue),
+	}
+
+	data, err := os.ReadFile(a.cachePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("读取分析缓存失败: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &a.cache); err != nil {
+			fmt.Printf("提示: 分析缓存已损坏，将重新分析: %v\n", err)
+			a.cache = make(map[int]*issueAnalysisCacheEntry)
+		}
+	}
+	return a, nil
+}
+
+// 分析单个issue，issue自上次分析后没有更新且模型和提示词相同时使用缓存结果
+func (a *issueAnalyzer) analyze(issue *github.Issue, comments []*github.IssueComment) (*issueAnalysis, error) {
+	updatedAt := issue.GetUpdatedAt().Format(time.RFC3339)
+	prompt := buildIssueAnalysisPrompt(issue, comments)
+	sum := sha256.Sum256([]byte(prompt))
+	promptHash := hex.EncodeToString(sum[:])
+	if entry := a.cache[issue.GetNumber()]; entry != nil && entry.UpdatedAt == updatedAt &&
+		entry.Model == a.model && entry.PromptHash == promptHash && entry.Analysis != nil {
+		a.record(issue, entry.Analysis)
+		return entry.Analysis, nil
+	}
+
+	completion, err := generateText(context.Background(), a.llm, prompt)
+	if err != nil {
+		return nil, err
+	}
+	analysis, err := parseIssueAnalysis(completion)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cache[issue.GetNumber()] = &issueAnalysisCacheEntry{
+		UpdatedAt:  updatedAt,
+		Model:      a.model,
+		PromptHash: promptHash,
+		Analysis:   analysis,
+	}
+	a.record(issue, analysis)
+	return analysis, nil
+}
+
+func (a *issueAnalyzer) record(issue *github.Issue, analysis *issueAnalysis) {
+	a.results[issue.GetNumber()] = analysis
+	a.issues[issue.GetNumber()] = issue
+}
+
+// 保存分析缓存
+func (a *issueAnalyzer) saveCache() error {
+	data, err := json.MarshalIndent(a.cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化分析缓存失败: %w", err)
+	}
+	return os.WriteFile(a.cachePath, data, 0644)
+}
+
+// 将本次运行的分析结果写入分诊CSV，r不为nil时对标题和分析内容脱敏
+func (a *issueAnalyzer) writeTriageCSV(outputDir string, r *redactor) (string, error) {
+	numbers := make([]int, 0, len(a.results))
+	for number := range a.results {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+
+	path := filepath.Join(outputDir, triageCSVFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"编号", "标题", "状态", "分类", "严重程度", "建议标签", "概要", "建议操作", "链接"})
+	for _, number := range numbers {
+		issue, analysis := a.issues[number], a.results[number]
+		source := fmt.Sprintf("issue #%d", number)
+		w.Write([]string{
+			strconv.Itoa(number),
+			r.redact(issue.GetTitle(), source),
+			issue.GetState(),
+			analysis.Category,
+			analysis.Severity,
+			strings.Join(analysis.SuggestedLabels, ", "),
+			r.redact(analysis.Summary, source),
+			r.redact(analysis.NextAction, source),
+			issue.GetHTMLURL(),
+		})
+	}
+	w.Flush()
+	return path, w.Error()
+}
+
+// 构建单个issue的分析提示词
+func buildIssueAnalysisPrompt(issue *github.Issue, comments []*github.IssueComment) string {
+	var sb strings.Builder
+	sb.WriteString("请分析下面的GitHub issue，并只返回一个JSON对象，不要包含其他内容。JSON格式如下：\n")
+	sb.WriteString(`{"category": "bug|feature|question|docs", "severity": "critical|high|medium|low", "suggested_labels": ["标签"], "summary": "一句话概要", "next_action": "建议的下一步操作"}`)
+	sb.WriteString("\n\n")
+
+	sb.WriteString(fmt.Sprintf("标题: %s\n", issue.GetTitle()))
+	sb.WriteString(fmt.Sprintf("状态: %s\n", issue.GetState()))
+	if labels := issueLabelsString(issue); labels != "" {
+		sb.WriteString(fmt.Sprintf("现有标签: %s\n", labels))
+	}
+	sb.WriteString("\n描述:\n")
+	sb.WriteString(truncateToTokens(issue.GetBody(), issueAnalysisMaxTokens))
+
+	if len(comments) > 0 {
+		var thread strings.Builder
+		for _, comment := range comments {
+			thread.WriteString(fmt.Sprintf("@%s: %s\n\n", comment.GetUser().GetLogin(), comment.GetBody()))
+		}
+		sb.WriteString("\n\n评论:\n")
+		sb.WriteString(truncateToTokens(thread.String(), issueAnalysisMaxTokens))
+	}
+	return sb.String()
+}
+
+// 从模型返回内容中解析分析结果，兼容包裹在代码块中的JSON
+func parseIssueAnalysis(completion string) (*issueAnalysis, error) {
+	start := strings.Index(completion, "{")
+	end := strings.LastIndex(completion, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("AI返回内容中没有JSON: %s", completion)
+	}
+
+	var analysis issueAnalysis
+	if err := json.Unmarshal([]byte(completion[start:end+1]), &analysis); err != nil {
+		return nil, fmt.Errorf("解析AI返回的JSON失败: %w", err)
+	}
+
+	analysis.Category = strings.ToLower(strings.TrimSpace(analysis.Category))
+	analysis.Severity = strings.ToLower(strings.TrimSpace(analysis.Severity))
+	valid := false
+	for _, category := range issueCategories {
+		if analysis.Category == category {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("AI返回了未知的分类: %s", analysis.Category)
+	}
+	return &analysis, nil
+}
+
+// 生成写入issue文件的AI分析部分
+func formatIssueAnalysis(analysis *issueAnalysis) string {
+	var sb strings.Builder
+	sb.WriteString("## AI分析\n\n")
+	sb.WriteString(fmt.Sprintf("- **分类**: %s\n", analysis.Category))
+	sb.WriteString(fmt.Sprintf("- **严重程度**: %s\n", analysis.Severity))
+	if len(analysis.SuggestedLabels) > 0 {
+		labels := make([]string, len(analysis.SuggestedLabels))
+		for i, label := range analysis.SuggestedLabels {
+			labels[i] = fmt.Sprintf("`%s`", label)
+		}
+		sb.WriteString(fmt.Sprintf("- **建议标签**: %s\n", strings.Join(labels, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("- **概要**: %s\n", analysis.Summary))
+	sb.WriteString(fmt.Sprintf("- **建议操作**: %s\n\n", analysis.NextAction))
+	return sb.String()
+}
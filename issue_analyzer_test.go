@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+)
+
+func TestWriteTriageCSVRedactsTitles(t *testing.T) {
+	issue := &github.Issue{
+		Number: github.Int(3),
+		Title:  github.String("token ghp_" + strings.Repeat("a1B2", 9) + " leaked"),
+		State:  github.String("open"),
+	}
+	a := &issueAnalyzer{
+		issues:  map[int]*github.Issue{3: issue},
+		results: map[int]*issueAnalysis{3: {Category: "bug", Summary: "mail admin@example.com", NextAction: "rotate"}},
+	}
+
+	tests := []struct {
+		name       string
+		redact     bool
+		wantSecret bool
+	}{
+		{name: "未启用脱敏", redact: false, wantSecret: true},
+		{name: "启用脱敏", redact: true, wantSecret: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newExportRedactor(&Config{RedactExport: tt.redact})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r != nil {
+				r.report = &redactionReport{counts: make(map[string]map[string]int)}
+			}
+
+			path, err := a.writeTriageCSV(t.TempDir(), r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range []string{"ghp_", "admin@example.com"} {
+				if got := strings.Contains(string(data), secret); got != tt.wantSecret {
+					t.Errorf("CSV中包含 %q = %v，期望 %v:\n%s", secret, got, tt.wantSecret, data)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteStaleCSVRedactsTitles(t *testing.T) {
+	r, err := newExportRedactor(&Config{RedactExport: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.report = &redactionReport{counts: make(map[string]map[string]int)}
+
+	stale := []*staleIssue{{
+		Issue: &github.Issue{
+			Number: github.Int(5),
+			Title:  github.String("cannot reach 10.0.0.12"),
+		},
+		Reasons:      []string{staleReasonInactive},
+		CloseMessage: "contact bob@example.com",
+	}}
+	path := filepath.Join(t.TempDir(), staleCSVFile)
+	if err := writeStaleCSV(path, stale, r); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"10.0.0.12", "bob@example.com"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("CSV中包含未脱敏的 %q:\n%s", secret, data)
+		}
+	}
+}
+
+func TestIssueAnalyzerCacheKeyIncludesModelAndPrompt(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	fixtures := `{"responses": [{"match": ".", "response": "{\"category\": \"bug\", \"severity\": \"high\", \"summary\": \"crash\", \"next_action\": \"fix\"}"}]}`
+	fixturesPath := filepath.Join(dir, "fixtures.json")
+	if err := os.WriteFile(fixturesPath, []byte(fixtures), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		AIProvider:     "mock",
+		AIModel:        "mock-a",
+		MockFixtures:   fixturesPath,
+		MockRecordFile: filepath.Join(dir, "records.jsonl"),
+	}
+	issue := &github.Issue{
+		Number:    github.Int(1),
+		Title:     github.String("crash on start"),
+		State:     github.String("open"),
+		UpdatedAt: &github.Timestamp{Time: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	comment := &github.IssueComment{
+		User: &github.User{Login: github.String("bob")},
+		Body: github.String("also happens on linux"),
+	}
+
+	analyze := func(cfg *Config, comments []*github.IssueComment) {
+		t.Helper()
+		a, err := newIssueAnalyzer(cfg, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := a.analyze(issue, comments); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.saveCache(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name         string
+		model        string
+		comments     []*github.IssueComment
+		wantRequests int
+	}{
+		{name: "首次分析", model: "mock-a", wantRequests: 1},
+		{name: "没有变化时使用缓存", model: "mock-a", wantRequests: 1},
+		{name: "模型变化时重新分析", model: "mock-b", wantRequests: 2},
+		{name: "提示词变化时重新分析", model: "mock-b", comments: []*github.IssueComment{comment}, wantRequests: 3},
+	}
+	for _, step := range steps {
+		cfg.AIModel = step.model
+		analyze(cfg, step.comments)
+		if got := len(readMockRecords(t, cfg.MockRecordFile)); got != step.wantRequests {
+			t.Errorf("%s: 请求次数 = %d，期望 %d", step.name, got, step.wantRequests)
+		}
+	}
+}
@@ -21,14 +21,25 @@ const (
 func main() {
 	// 定义命令行参数
 	var (
-		token     = flag.String("token", "", "GitHub API token")
-		aiToken   = flag.String("aiToken", "", "AI API token")
-		aiModel   = flag.String("aiModel", "deepseek-chat", "AI model name")
-		aiBaseURL = flag.String("aiBaseURL", "https://api.deepseek.com/v1/chat/completions", "AI base URL")
-
-		commentEnable = flag.Bool("comment", false, "是否下载issue评论")
-		aiEnable      = flag.Bool("ai", false, "是否使用AI分析issues")
-		chartEnable   = flag.Bool("chart", false, "是否生成图表分析")
+		token      = flag.String("token", "", "GitHub API token")
+		aiToken    = flag.String("aiToken", "", "AI API token")
+		aiProvider = flag.String("aiProvider", "openai", "AI服务提供方: openai, azure, ollama, anthropic, googleai, mock")
+		aiModel    = flag.String("aiModel", "deepseek-chat", "AI model name")
+		aiBaseURL  = flag.String("aiBaseURL", "https://api.deepseek.com/v1/chat/completions", "AI base URL")
+
+		commentEnable    = flag.Bool("comment", false, "是否下载issue评论")
+		aiEnable         = flag.Bool("ai", false, "是否使用AI分析issues")
+		aiIssueEnable    = flag.Bool("aiIssues", false, "是否使用AI逐个分析issues的分类、优先级和处理建议")
+		aiDiscussion     = flag.Bool("aiDiscussion", false, "是否为评论较多的issue生成AI讨论摘要")
+		chartEnable      = flag.Bool("chart", false, "是否生成图表分析")
+		chartList        = flag.String("chartList", "", "需要生成的图表，多个用逗号分隔，为空时生成所有图表")
+		chartTheme       = flag.String("chartTheme", defaultChartTheme, "图表主题，如 westeros, dark, macarons")
+		chartGranularity = flag.String("chartGranularity", defaultChartGranularity, "积压趋势图的统计周期: day, week, month, quarter")
+		chartIncludeBots = flag.Bool("chartIncludeBots", false, "人员分析图中包含机器人账号")
+		chartImages      = flag.Bool("chartImages", false, "将状态、标签和时间趋势图另存为SVG图片，并嵌入AI分析总结")
+		noAICache        = flag.Bool("noAICache", false, "不使用AI应答缓存，总是重新请求")
+		aiStream         = flag.Bool("aiStream", false, "是否将AI总结边生成边输出到控制台和文件")
+		redactExport     = flag.Bool("redact", false, "是否对导出的Markdown文件脱敏，发送给AI的内容总是脱敏")
 
 		outputDir   = flag.String("output", "", "指定输出目录")
 		summaryFile = flag.String("filename", "summary.md", "AI分析总结文件名")
@@ -55,6 +66,9 @@ func main() {
 		if config.AIToken != "" {
 			*aiToken = config.AIToken
 		}
+		if config.AIProvider != "" {
+			*aiProvider = config.AIProvider
+		}
 		if config.AIModel != "" {
 			*aiModel = config.AIModel
 		}
@@ -65,6 +79,19 @@ func main() {
 		*commentEnable = config.CommentEnable
 		*aiEnable = config.AiEnable
 		*chartEnable = config.ChartEnable
+		*aiIssueEnable = *aiIssueEnable || config.AIIssueEnable
+		*aiDiscussion = *aiDiscussion || config.DiscussionSummaryEnable
+		*redactExport = *redactExport || config.RedactExport
+		*chartImages = *chartImages || config.ChartImages
+		if len(config.ChartList) > 0 {
+			*chartList = strings.Join(config.ChartList, ",")
+		}
+		if config.ChartTheme != "" {
+			*chartTheme = config.ChartTheme
+		}
+		if config.ChartGranularity != "" {
+			*chartGranularity = config.ChartGranularity
+		}
 		if config.OutputDir != "" {
 			*outputDir = config.OutputDir
 		}
@@ -74,10 +101,46 @@ func main() {
 		fmt.Printf("config: %+v\n", config)
 	}
 
+	// 汇总最终生效的参数，供子命令使用
+	opts := &Config{AICacheEnable: true, AIMaxRetries: defaultAIMaxRetries, ChartExcludeBots: true}
+	if config != nil {
+		*opts = *config
+	}
+	opts.AICacheEnable = opts.AICacheEnable && !*noAICache
+	opts.ChartExcludeBots = opts.ChartExcludeBots && !*chartIncludeBots
+	opts.AIStream = opts.AIStream || *aiStream
+	opts.GitHubToken = *token
+	opts.AIToken = aiTokenOrEnv(*aiToken)
+	opts.AIProvider = *aiProvider
+	opts.AIModel = *aiModel
+	opts.AIBaseURL = *aiBaseURL
+	opts.CommentEnable = *commentEnable
+	opts.AiEnable = *aiEnable
+	opts.AIIssueEnable = *aiIssueEnable
+	opts.DiscussionSummaryEnable = *aiDiscussion
+	opts.RedactExport = *redactExport
+	opts.ChartEnable = *chartEnable
+	opts.ChartList = splitList(*chartList)
+	opts.ChartTheme = *chartTheme
+	opts.ChartGranularity = *chartGranularity
+	opts.ChartImages = *chartImages
+	opts.OutputDir = *outputDir
+	opts.SummaryFile = *summaryFile
+
 	// 检查是否提供了仓库参数
 	args := flag.Args()
 	if len(args) < 1 {
 		fmt.Println("使用方法: issue2file [选项] <仓库地址>")
+		fmt.Println("          issue2file [选项] <子命令> [子命令选项] [参数]")
+		fmt.Println("子命令:")
+		fmt.Println("  search      在已导出的issues中进行全文检索")
+		fmt.Println("  similar     查找与指定issue或文本语义相似的issues")
+		fmt.Println("  duplicates  检测可能重复的issues")
+		fmt.Println("  release-notes  根据里程碑或时间范围内关闭的issues生成发布说明")
+		fmt.Println("  stale       列出长期无更新、未指派或等待提交者回复的issues")
+		fmt.Println("  digest      生成每周或每月的issues周期报告")
+		fmt.Println("  ask         根据已导出的issues回答问题，并注明引用的issues")
+		fmt.Println("  chat        基于已导出的issues进行多轮问答")
 		fmt.Println("选项:")
 		flag.PrintDefaults()
 		fmt.Println("\n示例:")
@@ -85,26 +148,25 @@ func main() {
 		fmt.Println("  issue2file -token=xxx owner/repo # 使用token从指定仓库获取issues")
 		fmt.Println("  issue2file -ai-summary -ai-token=xxx owner/repo # 使用AI分析issues")
 		fmt.Println("  issue2file -config=config.cnf owner/repo # 使用配置文件")
+		fmt.Println("  issue2file search -dir=issues_owner_repo \"label:bug state:open OOM\" # 检索已导出的issues")
+		fmt.Println("  issue2file similar -dir=issues_owner_repo 42 # 查找与#42相似的issues")
 		os.Exit(1)
 	}
 
-	repoArg := args[0]
-
-	var owner, repo string
-	var err error
-
-	if repoArg == "." {
-		// 从当前目录的.git/config读取仓库信息
-		owner, repo, err = getRepoFromGitConfig()
-		if err != nil {
-			log.Fatalf("无法从.git/config获取仓库信息: %v", err)
+	// 执行子命令
+	if run, ok := subcommands[args[0]]; ok {
+		if err := run(opts, args[1:]); err != nil {
+			log.Fatalf("%s 执行失败: %v", args[0], err)
 		}
-	} else {
-		// 解析仓库地址
-		owner, repo, err = parseRepoURL(repoArg)
-		if err != nil {
-			log.Fatalf("无法解析仓库地址: %v", err)
+		if s := runRedactions.String(); s != "" {
+			fmt.Println(s)
 		}
+		return
+	}
+
+	owner, repo, err := resolveRepo(args[0])
+	if err != nil {
+		log.Fatal(err)
 	}
 
 	fmt.Printf("正在获取仓库 %s/%s 的issues...\n", owner, repo)
@@ -119,58 +181,137 @@ func main() {
 	}
 
 	// 创建输出目录
-	var output string
-	if *outputDir != "" {
-		output = *outputDir
-	} else {
-		output = fmt.Sprintf("issues_%s_%s", owner, repo)
-	}
+	output := exportDir(opts, owner, repo)
 	if err := os.MkdirAll(output, 0755); err != nil {
 		log.Fatalf("创建输出目录失败: %v", err)
 	}
 
-	// 保存issues为Markdown文件
+	export := &exportOptions{withComments: *commentEnable}
+
+	// 如果启用了逐个分析，创建分析器
+	if *aiIssueEnable {
+		if err := validateAIConfig(opts); err != nil {
+			log.Printf("警告: 启用了AI逐个分析但%v，跳过分析", err)
+		} else if export.analyzer, err = newIssueAnalyzer(opts, output); err != nil {
+			log.Printf("创建AI分析器失败: %v", err)
+		}
+	}
+
+	// 如果启用了讨论摘要，创建摘要生成器
+	if *aiDiscussion {
+		if !*commentEnable {
+			log.Printf("警告: 讨论摘要需要下载评论，请同时启用 -comment，跳过讨论摘要")
+		} else if err := validateAIConfig(opts); err != nil {
+			log.Printf("警告: 启用了讨论摘要但%v，跳过讨论摘要", err)
+		} else if export.summarizer, err = newDiscussionSummarizer(opts, output); err != nil {
+			log.Printf("创建讨论摘要生成器失败: %v", err)
+		}
+	}
+
+	// 如果启用了脱敏，导出的文件在写入前脱敏
+	if *redactExport {
+		if export.redactor, err = newRedactor(opts); err != nil {
+			log.Fatalf("创建脱敏规则失败: %v", err)
+		}
+	}
+
+	// 保存issues为Markdown文件，下载的评论留给图表使用
+	var comments map[int][]*github.IssueComment
+	if *commentEnable {
+		comments = make(map[int][]*github.IssueComment, len(issues))
+	}
 	for _, issue := range issues {
-		if err := saveIssueAsMarkdown(issue, output, owner, repo, client, *commentEnable); err != nil {
+		if issueComments, err := saveIssueAsMarkdown(issue, output, owner, repo, client, export); err != nil {
 			log.Printf("保存issue #%d 失败: %v", issue.GetNumber(), err)
 		} else {
+			if comments != nil {
+				comments[issue.GetNumber()] = issueComments
+			}
 			fmt.Printf("已保存 issue #%d: %s\n", issue.GetNumber(), issue.GetTitle())
 		}
 	}
 
-	fmt.Printf("完成！共保存了 %d 个issues到目录: %s\n", len(issues), outputDir)
-
-	// 如果启用了AI分析，生成总结
-	if *aiEnable {
-		// 优先使用命令行参数中的token
-		tokenKey := *aiToken
+	fmt.Printf("完成！共保存了 %d 个issues到目录: %s\n", len(issues), output)
 
-		// 如果命令行没有提供，尝试从环境变量获取GitHub token
-		if tokenKey == "" {
-			tokenKey = os.Getenv(EnvAIToken)
+	// 保存逐个分析的结果
+	if export.analyzer != nil {
+		if err := export.analyzer.saveCache(); err != nil {
+			log.Printf("保存AI分析缓存失败: %v", err)
+		}
+		if path, err := export.analyzer.writeTriageCSV(output, export.redactor); err != nil {
+			log.Printf("保存分诊结果失败: %v", err)
+		} else {
+			fmt.Printf("AI逐个分析完成，分诊结果已保存到: %s\n", path)
 		}
+	}
 
-		if tokenKey == "" {
-			log.Println("警告: 启用了AI分析但未提供AI Token，跳过分析")
+	// 如果启用了AI分析，生成总结
+	summaryWritten := false
+	if *aiEnable {
+		if err := validateAIConfig(opts); err != nil {
+			log.Printf("警告: 启用了AI分析但%v，跳过分析", err)
 		} else {
 			fmt.Println("正在使用AI分析issues...")
-			if err := generateAISummary(issues, output, *summaryFile, tokenKey, *aiModel, *aiBaseURL); err != nil {
+			if err := generateAISummary(issues, output, owner+"/"+repo, opts); err != nil {
 				log.Printf("AI分析失败: %v", err)
 			} else {
+				summaryWritten = true
 				fmt.Printf("AI分析完成，总结已保存到: %s\n", filepath.Join(output, *summaryFile))
 			}
 		}
 	}
 
+	// 输出本次运行的AI用量
+	if runAIUsage.Requests > 0 {
+		fmt.Println(runAIUsage.String(opts))
+	}
+
 	// 如果启用了图表生成，生成图表
 	if *chartEnable {
 		fmt.Println("正在生成图表...")
-		if err := generateCharts(issues, output); err != nil {
+		data := &chartData{issues: issues, comments: comments}
+		if data.milestones, err = fetchMilestones(client, owner, repo); err != nil {
+			log.Printf("%v，跳过里程碑进度图", err)
+		}
+		if entry, err := generateCharts(opts, data, output); err != nil {
 			log.Printf("图表生成失败: %v", err)
 		} else {
-			fmt.Printf("图表生成完成，可在 %s/charts 目录查看\n", output)
+			fmt.Printf("图表生成完成，可打开 %s 查看\n", entry)
+			// 图表图片生成成功后再嵌入总结文件
+			if opts.ChartImages && summaryWritten {
+				if err := embedChartImages(filepath.Join(output, summaryFileName(opts))); err != nil {
+					log.Printf("在总结中嵌入图表图片失败: %v", err)
+				}
+			}
 		}
 	}
+
+	// 保存脱敏报告
+	if written, err := runRedactions.write(filepath.Join(output, redactionReportFile)); err != nil {
+		log.Printf("%v", err)
+	} else if written {
+		fmt.Printf("%s，脱敏报告已保存到: %s\n", runRedactions.String(), filepath.Join(output, redactionReportFile))
+	}
+}
+
+// 子命令列表，第一个位置参数与子命令名相同时执行对应的子命令
+var subcommands = map[string]func(cfg *Config, args []string) error{
+	"search":        runSearch,
+	"similar":       runSimilar,
+	"duplicates":    runDuplicates,
+	"release-notes": runReleaseNotes,
+	"stale":         runStale,
+	"digest":        runDigest,
+	"ask":           runAsk,
+	"chat":          runChat,
+}
+
+// 导出issues的目录，未指定输出目录时为 issues_owner_repo
+func exportDir(cfg *Config, owner, repo string) string {
+	if cfg.OutputDir != "" {
+		return cfg.OutputDir
+	}
+	return fmt.Sprintf("issues_%s_%s", owner, repo)
 }
 
 // 创建GitHub客户端
@@ -199,15 +340,17 @@ func createGitHubClient(tokenParam string) *github.Client {
 
 // 获取仓库的所有issues
 func fetchIssues(client *github.Client, owner, repo string) ([]*github.Issue, error) {
+	return listIssues(client, owner, repo, &github.IssueListByRepoOptions{
+		State: "all", // 获取所有状态的issues
+	})
+}
+
+// 按条件分页获取仓库的issues
+func listIssues(client *github.Client, owner, repo string, opts *github.IssueListByRepoOptions) ([]*github.Issue, error) {
 	ctx := context.Background()
 
 	var allIssues []*github.Issue
-	opts := &github.IssueListByRepoOptions{
-		State: "all", // 获取所有状态的issues
-		ListOptions: github.ListOptions{
-			PerPage: 100, // 每页100个
-		},
-	}
+	opts.ListOptions.PerPage = 100 // 每页100个
 
 	for {
 		issues, resp, err := client.Issues.ListByRepo(ctx, owner, repo, opts)
@@ -254,39 +397,109 @@ func fetchComments(client *github.Client, owner, repo string, issueNumber int) (
 	return allComments, nil
 }
 
-// 将issue保存为Markdown文件
-func saveIssueAsMarkdown(issue *github.Issue, outputDir, owner, repo string, client *github.Client, withComments bool) error {
-	// 生成文件名，避免特殊字符
-	title := sanitizeFilename(issue.GetTitle())
-	filename := fmt.Sprintf("issue_%d_%s.md", issue.GetNumber(), title)
+// 获取仓库的所有里程碑
+func fetchMilestones(client *github.Client, owner, repo string) ([]*github.Milestone, error) {
+	ctx := context.Background()
+
+	var allMilestones []*github.Milestone
+	opts := &github.MilestoneListOptions{
+		State: "all",
+		ListOptions: github.ListOptions{
+			PerPage: 100,
+		},
+	}
+
+	for {
+		milestones, resp, err := client.Issues.ListMilestones(ctx, owner, repo, opts)
+		if err != nil {
+			return nil, fmt.Errorf("获取里程碑失败: %w", err)
+		}
+
+		allMilestones = append(allMilestones, milestones...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return allMilestones, nil
+}
+
+// 导出issue时的可选处理
+type exportOptions struct {
+	// 是否下载评论
+	withComments bool
+
+	// 不为nil时写入AI分析结果
+	analyzer *issueAnalyzer
+
+	// 不为nil时写入讨论摘要
+	summarizer *discussionSummarizer
+
+	// 不为nil时写入文件前脱敏
+	redactor *redactor
+}
+
+// 将issue保存为Markdown文件，返回下载的评论
+func saveIssueAsMarkdown(issue *github.Issue, outputDir, owner, repo string, client *github.Client, export *exportOptions) ([]*github.IssueComment, error) {
+	// 生成文件名，避免特殊字符，脱敏时文件名中的标题同样脱敏
+	title := issue.GetTitle()
+	if export.redactor != nil {
+		title = export.redactor.redact(title, fmt.Sprintf("issue #%d", issue.GetNumber()))
+	}
+	filename := fmt.Sprintf("issue_%d_%s.md", issue.GetNumber(), sanitizeFilename(title))
 	path := filepath.Join(outputDir, filename)
 
 	var comments []*github.IssueComment
 	var err error
 
 	// 根据参数决定是否获取评论
-	if withComments {
+	if export.withComments {
 		// 获取issue评论
 		comments, err = fetchComments(client, owner, repo, issue.GetNumber())
 		if err != nil {
-			return fmt.Errorf("获取评论失败: %w", err)
+			return nil, fmt.Errorf("获取评论失败: %w", err)
+		}
+	}
+
+	// AI分析失败时仍然保存issue
+	var analysis *issueAnalysis
+	if export.analyzer != nil {
+		if analysis, err = export.analyzer.analyze(issue, comments); err != nil {
+			log.Printf("AI分析issue #%d 失败: %v", issue.GetNumber(), err)
+		}
+	}
+
+	var discussion *discussionSummary
+	if export.summarizer != nil {
+		if discussion, err = export.summarizer.summarize(issue, comments); err != nil {
+			log.Printf("生成issue #%d 的讨论摘要失败: %v", issue.GetNumber(), err)
 		}
 	}
 
 	// 生成Markdown内容
-	content := generateMarkdownContent(issue, comments)
+	content := generateMarkdownContent(issue, comments, analysis, discussion)
+	if export.redactor != nil {
+		content = export.redactor.redact(content, fmt.Sprintf("issue #%d", issue.GetNumber()))
+	}
 
 	// 写入文件
-	return os.WriteFile(path, []byte(content), 0644)
+	return comments, os.WriteFile(path, []byte(content), 0644)
 }
 
 // 生成Markdown内容
-func generateMarkdownContent(issue *github.Issue, comments []*github.IssueComment) string {
+func generateMarkdownContent(issue *github.Issue, comments []*github.IssueComment, analysis *issueAnalysis, discussion *discussionSummary) string {
 	var sb strings.Builder
 
 	// 标题
 	sb.WriteString(fmt.Sprintf("# Issue #%d: %s\n\n", issue.GetNumber(), issue.GetTitle()))
 
+	// 讨论摘要
+	if discussion != nil {
+		sb.WriteString(formatDiscussionSummary(discussion))
+	}
+
 	// 基本信息
 	sb.WriteString("## 基本信息\n\n")
 	sb.WriteString(fmt.Sprintf("- **编号**: #%d\n", issue.GetNumber()))
@@ -331,6 +544,11 @@ func generateMarkdownContent(issue *github.Issue, comments []*github.IssueCommen
 
 	sb.WriteString(fmt.Sprintf("- **链接**: %s\n\n", issue.GetHTMLURL()))
 
+	// AI分析
+	if analysis != nil {
+		sb.WriteString(formatIssueAnalysis(analysis))
+	}
+
 	// 描述内容
 	if body := issue.GetBody(); body != "" {
 		sb.WriteString("## 描述\n\n")
@@ -381,3 +599,14 @@ func sanitizeFilename(filename string) string {
 
 	return strings.TrimSpace(cleaned)
 }
+
+// 拆分逗号分隔的列表，去掉空白和空项
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
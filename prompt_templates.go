@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// 未配置时使用的报告风格
+const defaultReportStyle = "summary"
+
+// 内置的报告风格
+type reportStyle struct {
+	// 报告标题
+	Title string
+
+	// 合并部分总结时描述报告的目标
+	Goal string
+
+	// 默认提示词模板
+	Template string
+
+	// 结构化输出时报告包含的章节，按顺序渲染
+	Sections []string
+}
+
+var reportStyles = map[string]*reportStyle{
+	"summary": {
+		Title: "GitHub Issues 分析总结",
+		Goal:  "分析总结",
+		Template: `以下是GitHub仓库{{if .Repo}} {{.Repo}} {{end}}的{{if gt .BatchCount 1}}部分issues（第{{.Batch}}/{{.BatchCount}}批）{{else}}issues列表{{end}}，请分析这些issues并提供总结：
+
+{{.IssueTable}}`,
+		Sections: []string{"主要问题", "功能需求", "趋势与风险", "建议"},
+	},
+	"release-notes": {
+		Title: "发布说明",
+		Goal:  "发布说明",
+		Template: `以下是GitHub仓库 {{.Repo}} 在 {{.StartDate}} 至 {{.EndDate}} 期间的{{if gt .BatchCount 1}}部分issues（第{{.Batch}}/{{.BatchCount}}批）{{else}}issues{{end}}。
+请以维护者的口吻为这些issues撰写发布说明，按新功能、问题修复、文档和其他改进分类，每条说明简洁并注明issue编号：
+
+{{.IssueTable}}`,
+		Sections: []string{"新功能", "问题修复", "文档", "其他改进"},
+	},
+	"weekly-triage": {
+		Title: "每周分诊摘要",
+		Goal:  "每周分诊摘要",
+		Template: `以下是GitHub仓库 {{.Repo}} 在 {{.StartDate}} 至 {{.EndDate}} 期间的{{if gt .BatchCount 1}}部分issues（第{{.Batch}}/{{.BatchCount}}批）{{else}}issues{{end}}。
+请为维护者撰写每周分诊摘要：指出需要优先处理的issues、尚未分类或缺少信息的issues、可以关闭的issues，并注明issue编号：
+
+{{.IssueTable}}`,
+		Sections: []string{"需要优先处理", "待分诊", "可以关闭", "本周概况"},
+	},
+	"health": {
+		Title: "维护者健康报告",
+		Goal:  "仓库健康报告",
+		Template: `以下是GitHub仓库 {{.Repo}} 在 {{.StartDate}} 至 {{.EndDate}} 期间的{{if gt .BatchCount 1}}部分issues（第{{.Batch}}/{{.BatchCount}}批）{{else}}issues{{end}}，共 {{.IssueCount}} 个。
+请从维护者的角度评估仓库的健康状况：issue积压情况、响应和处理效率、高频问题领域、潜在风险，并给出改进建议：
+
+{{.IssueTable}}`,
+		Sections: []string{"积压情况", "响应与处理效率", "高频问题领域", "风险", "改进建议"},
+	},
+}
+
+// 渲染提示词模板时可用的变量
+type promptData struct {
+	// 仓库名，格式为owner/repo
+	Repo string
+
+	// issues创建时间范围
+	StartDate string
+	EndDate   string
+
+	// issues总数
+	IssueCount int
+
+	// 当前批次的issues表格和描述
+	IssueTable string
+
+	// 当前批次和总批次数，从1开始
+	Batch      int
+	BatchCount int
+}
+
+// 结构化输出的报告内容
+type structuredReport struct {
+	Overview string `json:"overview"`
+	Sections []struct {
+		Title string   `json:"title"`
+		Items []string `json:"items"`
+	} `json:"sections"`
+}
+
+// 获取报告风格，name为空时使用默认风格
+func getReportStyle(name string) (*reportStyle, error) {
+	if name == "" {
+		name = defaultReportStyle
+	}
+	style, ok := reportStyles[name]
+	if !ok {
+		names := make([]string, 0, len(reportStyles))
+		for n := range reportStyles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("未知的报告风格: %s，可选: %s", name, strings.Join(names, ", "))
+	}
+	return style, nil
+}
+
+// 加载提示词模板，未指定模板文件时使用报告风格的默认模板
+func loadPromptTemplate(path string, style *reportStyle) (*template.Template, error) {
+	text := style.Template
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取提示词模板失败: %w", err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("prompt").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析提示词模板失败: %w", err)
+	}
+	return tmpl, nil
+}
+
+// 渲染提示词模板
+func renderPrompt(tmpl *template.Template, data *promptData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("渲染提示词模板失败: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// 根据issues生成模板变量
+func newPromptData(issues []*github.Issue, repoName string) *promptData {
+	data := &promptData{Repo: repoName, IssueCount: len(issues), Batch: 1, BatchCount: 1}
+	for i, issue := range issues {
+		createdAt := issue.GetCreatedAt().Format("2006-01-02")
+		if i == 0 || createdAt < data.StartDate {
+			data.StartDate = createdAt
+		}
+		if i == 0 || createdAt > data.EndDate {
+			data.EndDate = createdAt
+		}
+	}
+	return data
+}
+
+// 要求模型以JSON格式返回报告的说明
+func structuredOutputInstruction(style *reportStyle) string {
+	var sb strings.Builder
+	sb.WriteString("\n\n请只返回一个JSON对象，不要包含其他内容。JSON格式如下：\n")
+	sb.WriteString(`{"overview": "总体概述", "sections": [{"title": "章节标题", "items": ["要点"]}]}`)
+	sb.WriteString("\nsections按顺序包含以下章节，没有内容的章节items为空数组：")
+	sb.WriteString(strings.Join(style.Sections, "、"))
+	return sb.String()
+}
+
+// 将模型返回的JSON渲染为固定结构的报告章节
+func renderStructuredReport(completion string, style *reportStyle) (string, error) {
+	start := strings.Index(completion, "{")
+	end := strings.LastIndex(completion, "}")
+	if start < 0 || end < start {
+		return "", fmt.Errorf("AI返回内容中没有JSON")
+	}
+
+	var report structuredReport
+	if err := json.Unmarshal([]byte(completion[start:end+1]), &report); err != nil {
+		return "", fmt.Errorf("解析AI返回的JSON失败: %w", err)
+	}
+
+	items := make(map[string][]string)
+	var extra []string
+	for _, section := range report.Sections {
+		title := strings.TrimSpace(section.Title)
+		if _, ok := items[title]; !ok && !containsString(style.Sections, title) {
+			extra = append(extra, title)
+		}
+		items[title] = append(items[title], section.Items...)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("### 概述\n\n")
+	sb.WriteString(strings.TrimSpace(report.Overview))
+	sb.WriteString("\n\n")
+	for _, title := range append(style.Sections, extra...) {
+		sb.WriteString(fmt.Sprintf("### %s\n\n", title))
+		if len(items[title]) == 0 {
+			sb.WriteString("无\n\n")
+			continue
+		}
+		for _, item := range items[title] {
+			sb.WriteString(fmt.Sprintf("- %s\n", strings.TrimSpace(item)))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
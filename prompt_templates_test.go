@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+func TestRenderStructuredReport(t *testing.T) {
+	style := &reportStyle{Sections: []string{"主要问题", "建议"}}
+
+	tests := []struct {
+		name       string
+		completion string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "按风格的章节顺序渲染",
+			completion: `{"overview": " 总体良好 ", "sections": [{"title": "建议", "items": ["补充文档"]}, {"title": "主要问题", "items": ["启动崩溃 #1", " 内存泄漏 #2 "]}]}`,
+			want:       "### 概述\n\n总体良好\n\n### 主要问题\n\n- 启动崩溃 #1\n- 内存泄漏 #2\n\n### 建议\n\n- 补充文档",
+		},
+		{
+			name:       "缺少的章节显示为无",
+			completion: `{"overview": "概述", "sections": [{"title": "主要问题", "items": ["问题"]}]}`,
+			want:       "### 概述\n\n概述\n\n### 主要问题\n\n- 问题\n\n### 建议\n\n无",
+		},
+		{
+			name:       "JSON前后有其他内容",
+			completion: "好的，结果如下：\n```json\n{\"overview\": \"概述\", \"sections\": []}\n```",
+			want:       "### 概述\n\n概述\n\n### 主要问题\n\n无\n\n### 建议\n\n无",
+		},
+		{
+			name:       "重复章节合并，未知章节放在最后",
+			completion: `{"overview": "概述", "sections": [{"title": "其他", "items": ["x"]}, {"title": "建议", "items": ["a"]}, {"title": "建议", "items": ["b"]}]}`,
+			want:       "### 概述\n\n概述\n\n### 主要问题\n\n无\n\n### 建议\n\n- a\n- b\n\n### 其他\n\n- x",
+		},
+		{
+			name:       "没有JSON",
+			completion: "无法生成报告",
+			wantErr:    true,
+		},
+		{
+			name:       "JSON格式错误",
+			completion: `{"overview": "概述", "sections": [}`,
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderStructuredReport(tt.completion, style)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望返回错误，实际结果 %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("renderStructuredReport() =\n%s\n期望\n%s", got, tt.want)
+			}
+		})
+	}
+}
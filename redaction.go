@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tmc/langchaingo/embeddings"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// 脱敏报告文件名，位于输出目录下
+const redactionReportFile = "redactions.md"
+
+// 发送给AI的内容在脱敏报告中的来源名
+const redactionSourceAI = "AI请求"
+
+// RedactRule 用户自定义的脱敏规则
+type RedactRule struct {
+	// 规则名，出现在替换内容和脱敏报告中
+	Name string `mapstructure:"name"`
+
+	// 匹配需要脱敏内容的正则表达式
+	Pattern string `mapstructure:"pattern"`
+
+	// 替换内容，可以使用 ${1} 引用分组，为空时替换为 [REDACTED:规则名]
+	Replacement string `mapstructure:"replacement"`
+}
+
+// 内置的脱敏规则，按顺序应用
+var builtinRedactRules = []RedactRule{
+	{Name: "private-key", Pattern: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Name: "jwt", Pattern: `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`},
+	{Name: "github-token", Pattern: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`},
+	{Name: "openai-key", Pattern: `\bsk-[A-Za-z0-9_-]{20,}`},
+	{Name: "aws-access-key", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Name: "google-api-key", Pattern: `\bAIza[0-9A-Za-z_-]{35}\b`},
+	{Name: "slack-token", Pattern: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{Name: "secret", Pattern: `(?i)\b((?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?)[^\s"',;\[]{8,}`, Replacement: "${1}[REDACTED:secret]"},
+	{Name: "email", Pattern: `\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`},
+	{Name: "ipv4", Pattern: `\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`},
+}
+
+type compiledRedactRule struct {
+	name        string
+	regex       *regexp.Regexp
+	replacement string
+}
+
+// 按规则替换文本中的密钥和个人信息，并记录到脱敏报告
+type redactor struct {
+	rules  []compiledRedactRule
+	report *redactionReport
+}
+
+// 根据配置创建脱敏器：内置规则（排除已禁用的）加上用户自定义规则
+func newRedactor(cfg *Config) (*redactor, error) {
+	r := &redactor{report: runRedactions}
+
+	rules := make([]RedactRule, 0, len(builtinRedactRules)+len(cfg.RedactRules))
+	for _, rule := range builtinRedactRules {
+		if !containsString(cfg.RedactDisabledRules, rule.Name) {
+			rules = append(rules, rule)
+		}
+	}
+	rules = append(rules, cfg.RedactRules...)
+
+	for i, rule := range rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		regex, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("脱敏规则 %s 的正则表达式无效: %w", rule.Name, err)
+		}
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = fmt.Sprintf("[REDACTED:%s]", rule.Name)
+		}
+		r.rules = append(r.rules, compiledRedactRule{name: rule.Name, regex: regex, replacement: replacement})
+	}
+	return r, nil
+}
+
+// 导出文件使用的脱敏器，未启用redactExport时返回nil
+func newExportRedactor(cfg *Config) (*redactor, error) {
+	if !cfg.RedactExport {
+		return nil, nil
+	}
+	return newRedactor(cfg)
+}
+
+// 替换文本中所有匹配的内容，source为脱敏报告中的来源，r为nil时原样返回
+func (r *redactor) redact(text, source string) string {
+	if r == nil {
+		return text
+	}
+	for _, rule := range r.rules {
+		matches := rule.regex.FindAllStringIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		text = rule.regex.ReplaceAllString(text, rule.replacement)
+		r.report.record(source, rule.name, len(matches))
+	}
+	return text
+}
+
+// 对消息中的文本内容脱敏
+func (r *redactor) redactMessages(messages []llms.MessageContent) []llms.MessageContent {
+	redacted := make([]llms.MessageContent, len(messages))
+	for i, message := range messages {
+		parts := make([]llms.ContentPart, len(message.Parts))
+		for j, part := range message.Parts {
+			if text, ok := part.(llms.TextContent); ok {
+				part = llms.TextContent{Text: r.redact(text.Text, redactionSourceAI)}
+			}
+			parts[j] = part
+		}
+		redacted[i] = llms.MessageContent{Role: message.Role, Parts: parts}
+	}
+	return redacted
+}
+
+// 发送给向量化服务前对文本脱敏
+type redactingEmbedder struct {
+	embeddings.Embedder
+	redactor *redactor
+}
+
+func (e *redactingEmbedder) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	redacted := make([]string, len(texts))
+	for i, text := range texts {
+		redacted[i] = e.redactor.redact(text, redactionSourceAI)
+	}
+	return e.Embedder.EmbedDocuments(ctx, redacted)
+}
+
+func (e *redactingEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return e.Embedder.EmbedQuery(ctx, e.redactor.redact(text, redactionSourceAI))
+}
+
+// 本次运行的脱敏统计：每个来源中每条规则替换的次数，不记录被替换的内容
+type redactionReport struct {
+	mu     sync.Mutex
+	counts map[string]map[string]int
+}
+
+// 本次运行所有脱敏的统计
+var runRedactions = &redactionReport{counts: make(map[string]map[string]int)}
+
+func (r *redactionReport) record(source, rule string, count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.counts[source] == nil {
+		r.counts[source] = make(map[string]int)
+	}
+	r.counts[source][rule] += count
+}
+
+// 各规则的替换总数
+func (r *redactionReport) totals() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	totals := make(map[string]int)
+	for _, rules := range r.counts {
+		for rule, count := range rules {
+			totals[rule] += count
+		}
+	}
+	return totals
+}
+
+// 生成脱敏统计的一行说明，没有脱敏时返回空字符串
+func (r *redactionReport) String() string {
+	totals := r.totals()
+	if len(totals) == 0 {
+		return ""
+	}
+	items := make([]string, 0, len(totals))
+	for _, rule := range sortedKeys(totals) {
+		items = append(items, fmt.Sprintf("%s %d 处", rule, totals[rule]))
+	}
+	return "已脱敏: " + strings.Join(items, "，")
+}
+
+// 将脱敏报告写入文件，没有脱敏时不生成文件
+func (r *redactionReport) write(path string) (bool, error) {
+	totals := r.totals()
+	if len(totals) == 0 {
+		return false, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("# 脱敏报告\n\n")
+	sb.WriteString("以下内容在写入文件或发送给AI之前已被替换，报告中不包含被替换的原文。\n\n")
+	sb.WriteString("## 按规则统计\n\n")
+	sb.WriteString("| 规则 | 替换次数 |\n")
+	sb.WriteString("|------|----------|\n")
+	for _, rule := range sortedKeys(totals) {
+		sb.WriteString(fmt.Sprintf("| %s | %d |\n", rule, totals[rule]))
+	}
+
+	sb.WriteString("\n## 按来源统计\n\n")
+	sb.WriteString("| 来源 | 规则 | 替换次数 |\n")
+	sb.WriteString("|------|------|----------|\n")
+	sources := make([]string, 0, len(r.counts))
+	for source := range r.counts {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		for _, rule := range sortedKeys(r.counts[source]) {
+			sb.WriteString(fmt.Sprintf("| %s | %s | %d |\n", source, rule, r.counts[source][rule]))
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		return false, fmt.Errorf("保存脱敏报告失败: %w", err)
+	}
+	return true, nil
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
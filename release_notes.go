@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	log "github.com/sirupsen/logrus"
+)
+
+// 发布说明的默认文件名
+const releaseNotesFile = "release_notes.md"
+
+// 发布说明中单个issue描述在提示词中的token上限
+const releaseNotesIssueMaxTokens = 300
+
+// 标签到发布说明分类的映射，按顺序匹配标签名中的关键字
+var releaseNoteCategories = []struct {
+	title    string
+	keywords []string
+}{
+	{"新功能", []string{"feature", "enhancement", "功能", "需求"}},
+	{"问题修复", []string{"bug", "fix", "缺陷", "修复"}},
+	{"性能", []string{"performance", "perf", "性能"}},
+	{"文档", []string{"doc", "文档"}},
+}
+
+// 未匹配任何分类且没有标签的issues所在的分组
+const releaseNotesOtherGroup = "其他改进"
+
+// 按分类分组的已关闭issues
+type releaseNotesGroup struct {
+	Title  string
+	Issues []*github.Issue
+}
+
+// release-notes子命令：根据里程碑或时间范围内关闭的issues生成发布说明
+func runReleaseNotes(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("release-notes", flag.ExitOnError)
+	milestone := fs.String("milestone", "", "里程碑名称或编号")
+	since := fs.String("since", "", "起始日期（包含），格式: 2006-01-02")
+	until := fs.String("until", "", "结束日期（包含），格式: 2006-01-02")
+	output := fs.String("o", "", "输出文件路径，默认为导出目录下的 "+releaseNotesFile)
+	noAI := fs.Bool("noAI", false, "不使用AI，只生成按标签分组的列表")
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file release-notes [选项] <仓库地址>")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("未提供仓库地址")
+	}
+	if *milestone == "" && *since == "" && *until == "" {
+		return fmt.Errorf("请通过 -milestone 或 -since/-until 指定发布范围")
+	}
+
+	start, end, err := parseDateRange(*since, *until)
+	if err != nil {
+		return err
+	}
+
+	owner, repo, err := resolveRepo(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	client := createGitHubClient(cfg.GitHubToken)
+	dir := exportDir(cfg, owner, repo)
+
+	// 筛选已关闭的issues，按更新时间预先过滤，关闭时间一定不早于起始日期
+	opts := &github.IssueListByRepoOptions{State: "closed"}
+	if !start.IsZero() {
+		opts.Since = start
+	}
+	title := fmt.Sprintf("%s/%s", owner, repo)
+	if *milestone != "" {
+		m, err := findMilestone(client, owner, repo, *milestone)
+		if err != nil {
+			return err
+		}
+		opts.Milestone = strconv.Itoa(m.GetNumber())
+		title += " " + m.GetTitle()
+	}
+	if !start.IsZero() || !end.IsZero() {
+		title += fmt.Sprintf("（%s 至 %s）", valueOrDash(*since), valueOrDash(*until))
+	}
+
+	fmt.Printf("正在获取仓库 %s/%s 已关闭的issues...\n", owner, repo)
+	issues, err := listIssues(client, owner, repo, opts)
+	if err != nil {
+		return err
+	}
+	issues = filterReleaseIssues(issues, start, end)
+	if len(issues) == 0 {
+		return fmt.Errorf("指定范围内没有已关闭的issues")
+	}
+	fmt.Printf("共有 %d 个已关闭的issues\n", len(issues))
+
+	groups := groupIssuesForReleaseNotes(issues)
+
+	// 优先使用AI撰写，未配置AI或生成失败时使用按标签分组的列表
+	var notes string
+	if !*noAI {
+		if err := validateAIConfig(cfg); err != nil {
+			log.Printf("警告: %v，生成不含AI撰写内容的发布说明", err)
+		} else if notes, err = generateAIReleaseNotes(cfg, dir, title, groups); err != nil {
+			log.Printf("AI撰写发布说明失败: %v，生成不含AI撰写内容的发布说明", err)
+		}
+	}
+	if notes == "" {
+		notes = formatReleaseNotesList(groups)
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("# 发布说明: %s\n\n", title))
+	content.WriteString(notes)
+	content.WriteString("\n")
+
+	path := *output
+	if path == "" {
+		path = filepath.Join(dir, releaseNotesFile)
+	}
+	if parent := filepath.Dir(path); parent != "." {
+		if err := os.MkdirAll(parent, 0755); err != nil {
+			return fmt.Errorf("创建输出目录失败: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("保存发布说明失败: %w", err)
+	}
+	fmt.Printf("发布说明已保存到: %s\n", path)
+	if runAIUsage.Requests > 0 {
+		fmt.Println(runAIUsage.String(cfg))
+	}
+	return nil
+}
+
+// 解析日期范围，返回的结束时间为结束日期的次日零点，未指定的一端为零值
+func parseDateRange(since, until string) (start, end time.Time, err error) {
+	if since != "" {
+		if start, err = time.ParseInLocation("2006-01-02", since, time.Local); err != nil {
+			return start, end, fmt.Errorf("无效的起始日期 %s: %w", since, err)
+		}
+	}
+	if until != "" {
+		if end, err = time.ParseInLocation("2006-01-02", until, time.Local); err != nil {
+			return start, end, fmt.Errorf("无效的结束日期 %s: %w", until, err)
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return start, end, fmt.Errorf("起始日期不能晚于结束日期")
+	}
+	return start, end, nil
+}
+
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// 按名称或编号查找里程碑
+func findMilestone(client *github.Client, owner, repo, name string) (*github.Milestone, error) {
+	milestones, err := fetchMilestones(client, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	number, _ := strconv.Atoi(name)
+	for _, m := range milestones {
+		if m.GetTitle() == name || (number > 0 && m.GetNumber() == number) {
+			return m, nil
+		}
+	}
+	return nil, fmt.Errorf("未找到里程碑: %s", name)
+}
+
+// 筛选关闭时间在范围内、且不是以“不计划处理”关闭的issues，排除pull requests
+func filterReleaseIssues(issues []*github.Issue, start, end time.Time) []*github.Issue {
+	var result []*github.Issue
+	for _, issue := range issues {
+		if issue.IsPullRequest() || issue.GetStateReason() == "not_planned" {
+			continue
+		}
+		closedAt := issue.GetClosedAt().Time
+		if !start.IsZero() && closedAt.Before(start) {
+			continue
+		}
+		if !end.IsZero() && !closedAt.Before(end) {
+			continue
+		}
+		result = append(result, issue)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetNumber() < result[j].GetNumber()
+	})
+	return result
+}
+
+// 按标签将issues分组，常见标签归入固定分类，其余按第一个标签分组
+func groupIssuesForReleaseNotes(issues []*github.Issue) []*releaseNotesGroup {
+	var groups []*releaseNotesGroup
+	byTitle := make(map[string]*releaseNotesGroup)
+	add := func(title string, issue *github.Issue) {
+		group := byTitle[title]
+		if group == nil {
+			group = &releaseNotesGroup{Title: title}
+			byTitle[title] = group
+			groups = append(groups, group)
+		}
+		group.Issues = append(group.Issues, issue)
+	}
+
+	for _, issue := range issues {
+		add(releaseNotesCategory(issue), issue)
+	}
+
+	// 固定分类在前，按标签分组的在后，其他改进放在最后
+	order := make(map[string]int)
+	for i, category := range releaseNoteCategories {
+		order[category.title] = i
+	}
+	rank := func(title string) int {
+		if i, ok := order[title]; ok {
+			return i
+		}
+		if title == releaseNotesOtherGroup {
+			return len(order) + 1
+		}
+		return len(order)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		ri, rj := rank(groups[i].Title), rank(groups[j].Title)
+		if ri != rj {
+			return ri < rj
+		}
+		return groups[i].Title < groups[j].Title
+	})
+	return groups
+}
+
+// 根据issue的标签确定发布说明分类
+func releaseNotesCategory(issue *github.Issue) string {
+	for _, category := range releaseNoteCategories {
+		for _, label := range issue.Labels {
+			name := strings.ToLower(label.GetName())
+			for _, keyword := range category.keywords {
+				if strings.Contains(name, keyword) {
+					return category.title
+				}
+			}
+		}
+	}
+	if len(issue.Labels) > 0 {
+		return issue.Labels[0].GetName()
+	}
+	return releaseNotesOtherGroup
+}
+
+// 生成不使用AI的发布说明：按分组列出issues
+func formatReleaseNotesList(groups []*releaseNotesGroup) string {
+	var sb strings.Builder
+	for _, group := range groups {
+		sb.WriteString(fmt.Sprintf("## %s\n\n", group.Title))
+		for _, issue := range group.Issues {
+			sb.WriteString(fmt.Sprintf("- %s ([#%d](%s))\n", issue.GetTitle(), issue.GetNumber(), issue.GetHTMLURL()))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// 使用AI根据分组后的issues撰写发布说明，outputDir为AI缓存所在的导出目录
+// issues较多时按aiChunkTokens分批撰写后合并，有批次超出token上限时返回错误，由调用方改用按分组的列表
+func generateAIReleaseNotes(cfg *Config, outputDir, title string, groups []*releaseNotesGroup) (string, error) {
+	client, err := newAIClient(cfg, outputDir)
+	if err != nil {
+		return "", err
+	}
+	chunkTokens := cfg.AIChunkTokens
+	if chunkTokens <= 0 {
+		chunkTokens = defaultAIChunkTokens
+	}
+
+	const requirements = "请以维护者的口吻撰写一份Markdown格式的发布说明：每个分组使用二级标题，每条说明用一句话描述对用户的影响并注明issue编号（如 #12），" +
+		"可以合并相近的条目，不要编造列表中没有的内容，不要输出一级标题。\n\n"
+	intro := fmt.Sprintf("以下是 %s 中已关闭的issues，已按标签分组。", title)
+	overheadTokens := countTokens(intro+requirements) + aiPromptOverheadTokens
+	chunks := chunkReleaseNotesGroups(groups, chunkTokens-overheadTokens)
+
+	ctx := context.Background()
+	fmt.Println("正在使用AI撰写发布说明...")
+	if len(chunks) == 1 {
+		notes, err := generateText(ctx, client, intro+requirements+chunks[0])
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(notes) + "\n", nil
+	}
+
+	prompts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		prompts[i] = fmt.Sprintf("以下是 %s 中已关闭的部分issues（第%d/%d批），已按标签分组。", title, i+1, len(chunks)) + requirements + chunk
+	}
+	partials, skipped, err := mapIssueChunks(ctx, client, prompts, cfg.AIConcurrency)
+	if err != nil {
+		return "", err
+	}
+	if skipped > 0 {
+		return "", fmt.Errorf("受token上限限制，共有 %d/%d 批issues未能撰写", skipped, len(chunks))
+	}
+	notes, err := reducePartialSummaries(ctx, client, partials, chunkTokens, reportStyles["release-notes"],
+		"\n\n合并时每个分组使用二级标题，注明issue编号，不要输出一级标题。")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(notes) + "\n", nil
+}
+
+// 按token数将分组后的issues切分为多批，每批中的issues保留所在分组的标题
+func chunkReleaseNotesGroups(groups []*releaseNotesGroup, maxTokens int) []string {
+	var chunks []string
+	var current strings.Builder
+	currentTokens := 0
+	currentGroup := ""
+	for _, group := range groups {
+		for _, issue := range group.Issues {
+			line := formatReleaseNotesIssue(issue)
+			tokens := countTokens(line)
+			if currentTokens > 0 && currentTokens+tokens > maxTokens {
+				chunks = append(chunks, current.String())
+				current.Reset()
+				currentTokens, currentGroup = 0, ""
+			}
+			if currentGroup != group.Title {
+				header := fmt.Sprintf("## %s\n\n", group.Title)
+				if currentTokens > 0 {
+					header = "\n" + header
+				}
+				current.WriteString(header)
+				currentTokens += countTokens(header)
+				currentGroup = group.Title
+			}
+			current.WriteString(line)
+			currentTokens += tokens
+		}
+	}
+	if currentTokens > 0 || len(chunks) == 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// 提示词中的单个issue：编号、标题、标签和截断后的描述
+func formatReleaseNotesIssue(issue *github.Issue) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("- #%d %s", issue.GetNumber(), issue.GetTitle()))
+	if labels := issueLabelsString(issue); labels != "" {
+		sb.WriteString(fmt.Sprintf("（标签: %s）", labels))
+	}
+	sb.WriteString("\n")
+	if body := strings.TrimSpace(issue.GetBody()); body != "" {
+		sb.WriteString(fmt.Sprintf("  描述: %s\n", strings.ReplaceAll(truncateToTokens(body, releaseNotesIssueMaxTokens), "\n", " ")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v57/github"
+)
+
+// 生成n个带描述的issues，分为问题修复和新功能两组
+func newReleaseNotesGroups(n int) []*releaseNotesGroup {
+	groups := []*releaseNotesGroup{{Title: "问题修复"}, {Title: "新功能"}}
+	for i := 1; i <= n; i++ {
+		issue := &github.Issue{
+			Number: github.Int(i),
+			Title:  github.String(fmt.Sprintf("issue title %d", i)),
+			Body:   github.String(strings.Repeat("alpha beta gamma delta ", 20)),
+		}
+		g := groups[i%2]
+		g.Issues = append(g.Issues, issue)
+	}
+	return groups
+}
+
+func TestChunkReleaseNotesGroups(t *testing.T) {
+	groups := newReleaseNotesGroups(20)
+	chunks := chunkReleaseNotesGroups(groups, 300)
+	if len(chunks) < 2 {
+		t.Fatalf("批次数 = %d，期望多批", len(chunks))
+	}
+
+	seen := 0
+	for i, chunk := range chunks {
+		if countTokens(chunk) > 300 {
+			t.Errorf("第%d批超出token上限: %d", i+1, countTokens(chunk))
+		}
+		if !strings.HasPrefix(chunk, "## ") {
+			t.Errorf("第%d批没有以分组标题开头: %q", i+1, chunk)
+		}
+		seen += strings.Count(chunk, "\n- #")
+	}
+	if seen != 20 {
+		t.Errorf("所有批次共包含 %d 个issues，期望20", seen)
+	}
+}
+
+func TestGenerateAIReleaseNotesChunksLargeReleases(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+
+	notes, err := generateAIReleaseNotes(cfg, dir, "owner/repo v1.0", newReleaseNotesGroups(20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(notes) != "MERGED-SUMMARY" {
+		t.Errorf("发布说明 = %q，期望合并后的内容", notes)
+	}
+
+	records := readMockRecords(t, cfg.MockRecordFile)
+	batches := 0
+	for _, record := range records {
+		if record.Response == "PARTIAL-SUMMARY" {
+			batches++
+		}
+		if tokens := countTokens(record.Prompt); tokens > cfg.AIChunkTokens {
+			t.Errorf("请求超出aiChunkTokens: %d", tokens)
+		}
+	}
+	if batches < 2 || records[len(records)-1].Response != "MERGED-SUMMARY" {
+		t.Errorf("期望多批撰写后合并，实际请求: %d 批，最后一次 %q", batches, records[len(records)-1].Response)
+	}
+}
+
+func TestGenerateAIReleaseNotesFailsWhenChunksSkipped(t *testing.T) {
+	runTokenBudget = &tokenBudget{}
+	dir := t.TempDir()
+	cfg := newMockSummaryConfig(t, dir)
+	cfg.AIConcurrency = 1
+	cfg.AIMaxTotalTokens = 500
+
+	if _, err := generateAIReleaseNotes(cfg, dir, "owner/repo v1.0", newReleaseNotesGroups(20)); err == nil {
+		t.Fatal("有批次超出token上限时应返回错误，由调用方改用按分组的列表")
+	}
+}
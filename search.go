@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// 索引目录名，位于导出目录下
+const searchIndexDir = ".search_index"
+
+// 记录索引构建时间的内部键
+const searchIndexBuiltAtKey = "builtAt"
+
+// 记录建立索引的issue文件名的内部键
+const searchIndexFilesKey = "files"
+
+// 写入索引的issue文档
+type searchDocument struct {
+	Number   int      `json:"number"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Comments string   `json:"comments"`
+	Label    []string `json:"label"`
+	State    string   `json:"state"`
+	Author   string   `json:"author"`
+	Path     string   `json:"path"`
+}
+
+// 检索时支持的字段过滤
+var searchFilterFields = []string{"label", "state", "author"}
+
+// search子命令：在已导出的issues中进行全文检索
+func runSearch(cfg *Config, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	dir := fs.String("dir", cfg.OutputDir, "已导出issues的目录")
+	rebuild := fs.Bool("rebuild", false, "强制重建索引")
+	limit := fs.Int("limit", 10, "最多显示的结果数")
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file search [选项] <查询>")
+		fmt.Println("查询支持字段过滤: label:<标签> state:<open|closed> author:<用户名>")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *dir == "" {
+		return fmt.Errorf("未指定已导出issues的目录，请使用 -dir 参数")
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("未提供查询内容")
+	}
+
+	index, err := openSearchIndex(*dir, *rebuild)
+	if err != nil {
+		return err
+	}
+	defer index.Close()
+
+	req := bleve.NewSearchRequestOptions(buildSearchQuery(strings.Join(fs.Args(), " ")), *limit, 0, false)
+	req.Fields = []string{"number", "title", "state", "path"}
+	result, err := index.Search(req)
+	if err != nil {
+		return fmt.Errorf("检索失败: %w", err)
+	}
+
+	if result.Total == 0 {
+		fmt.Println("没有找到匹配的issues")
+		return nil
+	}
+
+	fmt.Printf("共找到 %d 个匹配的issues，显示前 %d 个:\n\n", result.Total, len(result.Hits))
+	for _, hit := range result.Hits {
+		number, _ := hit.Fields["number"].(float64)
+		fmt.Printf("%6.3f  #%d [%v] %v\n", hit.Score, int(number), hit.Fields["state"], hit.Fields["title"])
+		fmt.Printf("        %v\n", hit.Fields["path"])
+	}
+	return nil
+}
+
+// 打开检索索引，索引不存在、已过期或要求重建时重新构建
+func openSearchIndex(dir string, rebuild bool) (bleve.Index, error) {
+	indexPath := filepath.Join(dir, searchIndexDir)
+
+	if !rebuild {
+		index, err := bleve.Open(indexPath)
+		if err == nil {
+			stale, err := searchIndexStale(index, dir)
+			if err == nil && !stale {
+				return index, nil
+			}
+			index.Close()
+		} else if !errors.Is(err, bleve.ErrorIndexPathDoesNotExist) {
+			fmt.Printf("提示: 打开索引失败，将重新构建: %v\n", err)
+		}
+	}
+
+	return buildSearchIndex(dir, indexPath)
+}
+
+// 判断索引构建之后是否有issue文件被新增、删除、重命名或修改
+func searchIndexStale(index bleve.Index, dir string) (bool, error) {
+	value, err := index.GetInternal([]byte(searchIndexBuiltAtKey))
+	if err != nil || value == nil {
+		return true, err
+	}
+	builtAt, err := time.Parse(time.RFC3339Nano, string(value))
+	if err != nil {
+		return true, err
+	}
+
+	files, err := listExportedIssueFiles(dir)
+	if err != nil {
+		return true, err
+	}
+	indexed, err := index.GetInternal([]byte(searchIndexFilesKey))
+	if err != nil || string(indexed) != searchIndexFileList(files) {
+		return true, err
+	}
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || info.ModTime().After(builtAt) {
+			return true, err
+		}
+	}
+	return false, nil
+}
+
+// 根据已导出的issues构建索引
+func buildSearchIndex(dir, indexPath string) (bleve.Index, error) {
+	issues, err := loadExportedIssues(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("正在为 %d 个issues构建索引...\n", len(issues))
+	builtAt := time.Now()
+
+	if err := os.RemoveAll(indexPath); err != nil {
+		return nil, fmt.Errorf("删除旧索引失败: %w", err)
+	}
+	index, err := bleve.New(indexPath, newSearchMapping())
+	if err != nil {
+		return nil, fmt.Errorf("创建索引失败: %w", err)
+	}
+
+	batch := index.NewBatch()
+	for _, issue := range issues {
+		if err := batch.Index(strconv.Itoa(issue.Number), newSearchDocument(issue)); err != nil {
+			index.Close()
+			return nil, fmt.Errorf("索引issue #%d 失败: %w", issue.Number, err)
+		}
+	}
+	if err := index.Batch(batch); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("写入索引失败: %w", err)
+	}
+	if err := index.SetInternal([]byte(searchIndexBuiltAtKey), []byte(builtAt.Format(time.RFC3339Nano))); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("写入索引失败: %w", err)
+	}
+	files := make([]string, len(issues))
+	for i, issue := range issues {
+		files[i] = issue.Path
+	}
+	sort.Strings(files)
+	if err := index.SetInternal([]byte(searchIndexFilesKey), []byte(searchIndexFileList(files))); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("写入索引失败: %w", err)
+	}
+
+	return index, nil
+}
+
+// 已排序的issue文件名列表，用于比较索引中的文件与目录中的文件是否一致
+func searchIndexFileList(files []string) string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = filepath.Base(file)
+	}
+	return strings.Join(names, "\n")
+}
+
+// 索引字段映射：文本字段分词，过滤字段按关键字精确匹配
+func newSearchMapping() *mapping.IndexMappingImpl {
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = keyword.Name
+
+	storedOnly := bleve.NewTextFieldMapping()
+	storedOnly.Index = false
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("number", bleve.NewNumericFieldMapping())
+	doc.AddFieldMappingsAt("title", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("body", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("comments", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("path", storedOnly)
+	for _, field := range searchFilterFields {
+		doc.AddFieldMappingsAt(field, keywordField)
+	}
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	return m
+}
+
+// 将已导出的issue转换为索引文档
+func newSearchDocument(issue *exportedIssue) *searchDocument {
+	var comments strings.Builder
+	for _, comment := range issue.Comments {
+		comments.WriteString(comment.Body)
+		comments.WriteString("\n\n")
+	}
+
+	labels := make([]string, len(issue.Labels))
+	for i, label := range issue.Labels {
+		labels[i] = strings.ToLower(label)
+	}
+
+	return &searchDocument{
+		Number:   issue.Number,
+		Title:    issue.Title,
+		Body:     issue.Body,
+		Comments: comments.String(),
+		Label:    labels,
+		State:    strings.ToLower(issue.State),
+		Author:   strings.ToLower(issue.Author),
+		Path:     issue.Path,
+	}
+}
+
+// 解析查询：field:value 形式的词作为过滤条件，其余作为全文检索内容
+func buildSearchQuery(input string) query.Query {
+	var conjuncts []query.Query
+	var text []string
+
+	for _, term := range splitSearchTerms(input) {
+		field, value, ok := strings.Cut(term, ":")
+		if ok && value != "" && containsString(searchFilterFields, field) {
+			q := bleve.NewTermQuery(strings.ToLower(value))
+			q.SetField(field)
+			conjuncts = append(conjuncts, q)
+			continue
+		}
+		text = append(text, term)
+	}
+
+	if len(text) > 0 {
+		match := strings.Join(text, " ")
+		title := bleve.NewMatchQuery(match)
+		title.SetField("title")
+		title.SetBoost(3)
+		body := bleve.NewMatchQuery(match)
+		body.SetField("body")
+		comments := bleve.NewMatchQuery(match)
+		comments.SetField("comments")
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(title, body, comments))
+	}
+
+	if len(conjuncts) == 0 {
+		return bleve.NewMatchAllQuery()
+	}
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
+
+// 按空白切分查询，双引号内的内容视为一个整体，如 label:"good first issue"
+func splitSearchTerms(input string) []string {
+	var terms []string
+	var current strings.Builder
+	inQuotes := false
+
+	for _, r := range input {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if current.Len() > 0 {
+				terms = append(terms, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		terms = append(terms, current.String())
+	}
+	return terms
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v57/github"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// stale报告的文件名，位于输出目录下
+	staleReportFile = "stale.md"
+	staleCSVFile    = "stale.csv"
+
+	// 默认超过该天数没有更新的issue视为不活跃
+	defaultStaleDays = 90
+
+	// 生成关闭说明时issue描述在提示词中的token上限
+	staleIssueMaxTokens = 1500
+)
+
+// issue需要关注的原因，按以下顺序排列
+const (
+	staleReasonInactive   = "长期无更新"
+	staleReasonAwaiting   = "等待提交者回复"
+	staleReasonUnassigned = "未指派"
+)
+
+// 没有标签的issues所在的分组
+const staleNoLabelGroup = "无标签"
+
+// 评论者与仓库的关系为这些值时视为维护者
+var maintainerAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// 需要关注的未关闭issue及其原因
+type staleIssue struct {
+	Issue   *github.Issue
+	Reasons []string
+
+	// 最后一次更新距今的天数
+	IdleDays int
+
+	// AI建议的关闭说明，未启用时为空
+	CloseMessage string
+}
+
+// 是否适合关闭：长期不活跃或一直在等待提交者回复
+func (s *staleIssue) closable() bool {
+	return len(s.Reasons) > 0 && s.Reasons[0] != staleReasonUnassigned
+}
+
+// stale子命令：列出长期无更新、未指派或等待提交者回复的未关闭issues
+func runStale(cfg *Config, args []string) error {
+	days := cfg.StaleDays
+	if days <= 0 {
+		days = defaultStaleDays
+	}
+
+	fs := flag.NewFlagSet("stale", flag.ExitOnError)
+	fs.IntVar(&days, "days", days, "超过该天数没有更新的issue视为不活跃")
+	output := fs.String("o", "", "输出目录，默认为导出issues的目录")
+	withAI := fs.Bool("ai", false, "使用AI为可以关闭的issues撰写关闭说明")
+	fs.Usage = func() {
+		fmt.Println("使用方法: issue2file stale [选项] <仓库地址>")
+		fmt.Println("选项:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("未提供仓库地址")
+	}
+	if days <= 0 {
+		return fmt.Errorf("无效的天数: %d", days)
+	}
+
+	owner, repo, err := resolveRepo(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	client := createGitHubClient(cfg.GitHubToken)
+
+	fmt.Printf("正在获取仓库 %s/%s 未关闭的issues...\n", owner, repo)
+	issues, err := listIssues(client, owner, repo, &github.IssueListByRepoOptions{State: "open"})
+	if err != nil {
+		return err
+	}
+
+	// 只有有评论的issue才需要获取评论判断是否在等待提交者回复
+	now := time.Now()
+	var stale []*staleIssue
+	for _, issue := range issues {
+		if issue.IsPullRequest() {
+			continue
+		}
+		var comments []*github.IssueComment
+		if issue.GetComments() > 0 {
+			if comments, err = fetchComments(client, owner, repo, issue.GetNumber()); err != nil {
+				log.Printf("获取issue #%d 的评论失败: %v", issue.GetNumber(), err)
+			}
+		}
+		if s := checkStaleIssue(issue, comments, days, now); s != nil {
+			stale = append(stale, s)
+		}
+	}
+	fmt.Printf("共有 %d 个未关闭的issues，其中 %d 个需要关注\n", len(issues), len(stale))
+
+	dir := *output
+	if dir == "" {
+		dir = exportDir(cfg, owner, repo)
+	}
+
+	if *withAI {
+		if err := validateAIConfig(cfg); err != nil {
+			log.Printf("警告: %v，跳过生成关闭说明", err)
+		} else if err := generateStaleCloseMessages(cfg, dir, stale, days); err != nil {
+			log.Printf("生成关闭说明失败: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("创建输出目录失败: %w", err)
+	}
+
+	// 导出文件脱敏时，报告和CSV中的标题、关闭说明同样脱敏
+	r, err := newExportRedactor(cfg)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%s/%s", owner, repo)
+	report := r.redact(formatStaleReport(title, stale, days, now), staleReportFile)
+	if err := os.WriteFile(filepath.Join(dir, staleReportFile), []byte(report), 0644); err != nil {
+		return fmt.Errorf("保存stale报告失败: %w", err)
+	}
+	if err := writeStaleCSV(filepath.Join(dir, staleCSVFile), stale, r); err != nil {
+		return fmt.Errorf("保存stale列表失败: %w", err)
+	}
+	fmt.Printf("stale报告已保存到: %s 和 %s\n", filepath.Join(dir, staleReportFile), filepath.Join(dir, staleCSVFile))
+	if runAIUsage.Requests > 0 {
+		fmt.Println(runAIUsage.String(cfg))
+	}
+	return nil
+}
+
+// 检查未关闭的issue是否需要关注，不需要时返回nil
+func checkStaleIssue(issue *github.Issue, comments []*github.IssueComment, days int, now time.Time) *staleIssue {
+	s := &staleIssue{Issue: issue, IdleDays: int(now.Sub(issue.GetUpdatedAt().Time).Hours() / 24)}
+	if s.IdleDays >= days {
+		s.Reasons = append(s.Reasons, staleReasonInactive)
+	}
+	if awaitingReporter(issue, comments) {
+		s.Reasons = append(s.Reasons, staleReasonAwaiting)
+	}
+	if len(issue.Assignees) == 0 && issue.Assignee == nil {
+		s.Reasons = append(s.Reasons, staleReasonUnassigned)
+	}
+	if len(s.Reasons) == 0 {
+		return nil
+	}
+	return s
+}
+
+// 最后一条评论由维护者发表，且维护者不是提交者本人时，认为在等待提交者回复
+func awaitingReporter(issue *github.Issue, comments []*github.IssueComment) bool {
+	if len(comments) == 0 {
+		return false
+	}
+	last := comments[len(comments)-1]
+	for _, comment := range comments {
+		if comment.GetCreatedAt().After(last.GetCreatedAt().Time) {
+			last = comment
+		}
+	}
+	if last.GetUser().GetLogin() == issue.GetUser().GetLogin() || isBotUser(last.GetUser()) {
+		return false
+	}
+	return containsString(maintainerAssociations, last.GetAuthorAssociation())
+}
+
+// 按标签将issues分组，带有多个标签的issue出现在每个标签的分组中，分组按issues数量排序
+func groupStaleIssues(stale []*staleIssue) ([]string, map[string][]*staleIssue) {
+	groups := make(map[string][]*staleIssue)
+	counts := make(map[string]int)
+	for _, s := range stale {
+		if len(s.Issue.Labels) == 0 {
+			groups[staleNoLabelGroup] = append(groups[staleNoLabelGroup], s)
+			continue
+		}
+		for _, label := range s.Issue.Labels {
+			groups[label.GetName()] = append(groups[label.GetName()], s)
+			counts[label.GetName()]++
+		}
+	}
+
+	var names []string
+	for _, item := range sortedCounts(counts, 0) {
+		names = append(names, item.Name)
+	}
+	// 无标签的分组放在最后
+	if len(groups[staleNoLabelGroup]) > 0 {
+		names = append(names, staleNoLabelGroup)
+	}
+	for _, name := range names {
+		sort.Slice(groups[name], func(i, j int) bool {
+			return groups[name][i].IdleDays > groups[name][j].IdleDays
+		})
+	}
+	return names, groups
+}
+
+// 生成Markdown格式的stale报告
+func formatStaleReport(title string, stale []*staleIssue, days int, now time.Time) string {
+	reasonCounts := make(map[string]int)
+	for _, s := range stale {
+		for _, reason := range s.Reasons {
+			reasonCounts[reason]++
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Stale Issues: %s\n\n", title))
+	sb.WriteString(fmt.Sprintf("生成时间: %s\n\n", now.Format("2006-01-02 15:04")))
+	sb.WriteString(fmt.Sprintf("- %s（超过 %d 天）: %d 个\n", staleReasonInactive, days, reasonCounts[staleReasonInactive]))
+	sb.WriteString(fmt.Sprintf("- %s（最后一条评论来自维护者）: %d 个\n", staleReasonAwaiting, reasonCounts[staleReasonAwaiting]))
+	sb.WriteString(fmt.Sprintf("- %s: %d 个\n\n", staleReasonUnassigned, reasonCounts[staleReasonUnassigned]))
+
+	if len(stale) == 0 {
+		sb.WriteString("没有需要关注的issues。\n")
+		return sb.String()
+	}
+
+	names, groups := groupStaleIssues(stale)
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf("## %s (%d)\n\n", name, len(groups[name])))
+		sb.WriteString("| Issue | 标题 | 原因 | 无更新天数 | 提交者 |\n")
+		sb.WriteString("|-------|------|------|------------|--------|\n")
+		for _, s := range groups[name] {
+			sb.WriteString(fmt.Sprintf("| [#%d](%s) | %s | %s | %d | @%s |\n",
+				s.Issue.GetNumber(), s.Issue.GetHTMLURL(),
+				strings.ReplaceAll(s.Issue.GetTitle(), "|", "\\|"),
+				strings.Join(s.Reasons, ", "), s.IdleDays, s.Issue.GetUser().GetLogin()))
+		}
+		sb.WriteString("\n")
+	}
+
+	// AI建议的关闭说明，每个issue只列出一次
+	var closable []*staleIssue
+	for _, s := range stale {
+		if s.CloseMessage != "" {
+			closable = append(closable, s)
+		}
+	}
+	if len(closable) > 0 {
+		sb.WriteString("## 建议的关闭说明\n\n")
+		for _, s := range closable {
+			sb.WriteString(fmt.Sprintf("### #%d %s\n\n", s.Issue.GetNumber(), s.Issue.GetTitle()))
+			for _, line := range strings.Split(s.CloseMessage, "\n") {
+				sb.WriteString("> " + line + "\n")
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+// 将stale列表写入CSV，每个issue一行，r不为nil时对标题和关闭说明脱敏
+func writeStaleCSV(path string, stale []*staleIssue, r *redactor) error {
+	sorted := append([]*staleIssue(nil), stale...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Issue.GetNumber() < sorted[j].Issue.GetNumber()
+	})
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"编号", "标题", "标签", "原因", "无更新天数", "提交者", "指派人", "最后更新", "建议关闭说明", "链接"})
+	for _, s := range sorted {
+		var assignees []string
+		for _, assignee := range s.Issue.Assignees {
+			assignees = append(assignees, assignee.GetLogin())
+		}
+		source := fmt.Sprintf("issue #%d", s.Issue.GetNumber())
+		w.Write([]string{
+			strconv.Itoa(s.Issue.GetNumber()),
+			r.redact(s.Issue.GetTitle(), source),
+			issueLabelsString(s.Issue),
+			strings.Join(s.Reasons, ", "),
+			strconv.Itoa(s.IdleDays),
+			s.Issue.GetUser().GetLogin(),
+			strings.Join(assignees, ", "),
+			s.Issue.GetUpdatedAt().Format("2006-01-02"),
+			r.redact(s.CloseMessage, source),
+			s.Issue.GetHTMLURL(),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// 使用AI为长期无更新或等待提交者回复的issues撰写关闭说明，outputDir为AI缓存所在的目录
+func generateStaleCloseMessages(cfg *Config, outputDir string, stale []*staleIssue, days int) error {
+	client, err := newAIClient(cfg, outputDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("正在使用AI撰写关闭说明...")
+	for _, s := range stale {
+		if !s.closable() {
+			continue
+		}
+		var prompt strings.Builder
+		prompt.WriteString("下面的GitHub issue将被作为stale关闭。请以维护者的口吻写一段简短、礼貌的关闭说明（不超过3句话），")
+		prompt.WriteString("说明关闭原因，并告诉提交者如果问题仍然存在可以补充信息后重新打开。只输出说明内容，语言与issue保持一致。\n\n")
+		prompt.WriteString(fmt.Sprintf("关闭原因: %s（%d 天无更新，阈值 %d 天）\n", strings.Join(s.Reasons, ", "), s.IdleDays, days))
+		prompt.WriteString(fmt.Sprintf("标题: %s\n", s.Issue.GetTitle()))
+		if labels := issueLabelsString(s.Issue); labels != "" {
+			prompt.WriteString(fmt.Sprintf("标签: %s\n", labels))
+		}
+		if body := strings.TrimSpace(s.Issue.GetBody()); body != "" {
+			prompt.WriteString(fmt.Sprintf("描述:\n%s\n", truncateToTokens(body, staleIssueMaxTokens)))
+		}
+
+		message, err := generateText(context.Background(), client, prompt.String())
+		if err != nil {
+			log.Printf("生成issue #%d 的关闭说明失败: %v", s.Issue.GetNumber(), err)
+			continue
+		}
+		s.CloseMessage = strings.TrimSpace(message)
+	}
+	return nil
+}
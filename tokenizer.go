@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// 统计token使用的编码，对非OpenAI模型也作为近似估算
+const tokenEncodingName = "cl100k_base"
+
+// 无法加载编码时，按每个token约4个字符估算
+const approxCharsPerToken = 4
+
+var (
+	tokenEncoding     *tiktoken.Tiktoken
+	tokenEncodingOnce sync.Once
+)
+
+// 获取token编码，使用内置的离线词表，不需要联网下载
+func getTokenEncoding() *tiktoken.Tiktoken {
+	tokenEncodingOnce.Do(func() {
+		tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+		encoding, err := tiktoken.GetEncoding(tokenEncodingName)
+		if err == nil {
+			tokenEncoding = encoding
+		}
+	})
+	return tokenEncoding
+}
+
+// 计算文本的token数量
+func countTokens(text string) int {
+	encoding := getTokenEncoding()
+	if encoding == nil {
+		return len([]rune(text)) / approxCharsPerToken
+	}
+	return len(encoding.Encode(text, nil, nil))
+}
+
+// 将文本截断到不超过maxTokens个token
+func truncateToTokens(text string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+
+	encoding := getTokenEncoding()
+	if encoding == nil {
+		runes := []rune(text)
+		if len(runes) > maxTokens*approxCharsPerToken {
+			return string(runes[:maxTokens*approxCharsPerToken])
+		}
+		return text
+	}
+
+	tokens := encoding.Encode(text, nil, nil)
+	if len(tokens) <= maxTokens {
+		return text
+	}
+	// 截断位置可能落在多字节字符中间，去掉不完整的字符
+	return strings.ToValidUTF8(encoding.Decode(tokens[:maxTokens]), "")
+}